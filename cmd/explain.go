@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/xhkzeroone/go-database/repo"
+)
+
+// explain lệnh con "explain": không có tên hàm thì in câu SQL (DryRun) của mọi hàm dynamic đã wiring,
+// có tên hàm thì chạy EXPLAIN (-analyze: EXPLAIN ANALYZE) với các tham số mẫu, kết quả dạng JSON:
+//
+//	go run ./cmd explain
+//	go run ./cmd explain FindAllByPartnerIdAndStatus p1 active
+//	go run ./cmd explain -analyze FindByStatusIn '["active","locked"]'
+func explain(ctx context.Context, r *repo.Repository[UserModel, uuid.UUID], args []string) error {
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	analyze := flags.Bool("analyze", false, "chạy EXPLAIN ANALYZE (thực thi truy vấn)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: explain [-analyze] [method [args...]]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if flags.NArg() == 0 {
+		stmts, err := r.Statements(ctx)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(stmts)
+	}
+
+	method, params := flags.Arg(0), sampleArgs(flags.Args()[1:])
+	var res *repo.ExplainResult
+	var err error
+	if *analyze {
+		res, err = r.ExplainAnalyze(ctx, method, params...)
+	} else {
+		res, err = r.Explain(ctx, method, params...)
+	}
+	if err != nil {
+		return err
+	}
+	return enc.Encode(res)
+}
+
+// sampleArgs tham số mẫu từ dòng lệnh: giá trị JSON (10, true, null, ["a","b"] cho In) hoặc chuỗi
+func sampleArgs(args []string) []any {
+	params := make([]any, len(args))
+	for i, arg := range args {
+		var v any
+		if err := json.Unmarshal([]byte(arg), &v); err != nil {
+			v = arg
+		}
+		params[i] = v
+	}
+	return params
+}
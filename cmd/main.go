@@ -2,19 +2,21 @@ package main
 
 import (
 	"context"
+	"embed"
 	"fmt"
+	"iter"
+	"os"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/xhkzeroone/go-database/db"
 	"github.com/xhkzeroone/go-database/repo"
-	"gorm.io/gorm"
 )
 
 // --- Ví dụ sử dụng:
 
 type UserModel struct {
-	ID        uuid.UUID `gorm:"primarykey;column:id;type:uuid"`
+	ID        uuid.UUID `gorm:"primarykey;column:id;type:uuid" repo:"@GeneratedValue(uuid)"`
 	PartnerId string    `gorm:"column:partner_id"`
 	Total     int       `gorm:"column:total"`
 	UserName  string    `gorm:"column:user_name"`
@@ -22,29 +24,25 @@ type UserModel struct {
 	LastName  string    `gorm:"column:last_name"`
 	Email     string    `gorm:"column:email"`
 	Status    string    `gorm:"column:status"`
-	CreatedAt time.Time `gorm:"column:created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at"`
+	CreatedAt time.Time `gorm:"column:created_at" repo:"@CreatedDate"`
+	UpdatedAt time.Time `gorm:"column:updated_at" repo:"@LastModifiedDate"`
+	CreatedBy string    `gorm:"column:created_by" repo:"@CreatedBy"`
+	UpdatedBy string    `gorm:"column:updated_by" repo:"@LastModifiedBy"`
 }
 
 func (u *UserModel) TableName() string {
 	return "user_tbl"
 }
 
-func (u *UserModel) BeforeCreate(ctx *gorm.DB) (err error) {
-	u.ID = uuid.New()
-	u.CreatedAt = time.Now()
-	return
-}
-
-func (u *UserModel) BeforeUpdate(ctx *gorm.DB) (err error) {
-	u.UpdatedAt = time.Now()
-	return
-}
-
 func (u *UserModel) GetTotal() int {
 	return u.Total
 }
 
+//go:embed queries/*.sql
+var queryFiles embed.FS
+
+//go:generate go run ./repogen -type UserRepository
+
 type UserRepository struct {
 	*repo.Repository[UserModel, uuid.UUID]
 	FindByUserName                     func(ctx context.Context, username string) (*UserModel, error)                                 `repo:"@Query"`
@@ -62,6 +60,71 @@ type UserRepository struct {
 	FindByCreatedAtBetween   func(ctx context.Context, from, to time.Time) (*UserModel, error) `repo:"@Query"`
 	FindByCreatedAtIsNull    func(ctx context.Context) (*UserModel, error)                     `repo:"@Query"`
 	FindByCreatedAtIsNotNull func(ctx context.Context) (*UserModel, error)                     `repo:"@Query"`
+
+	// First/TopN/Distinct và giới hạn runtime
+	FindFirstByStatusOrderByCreatedAtDesc func(ctx context.Context, status string) (*UserModel, error)                    `repo:"@Query"`
+	FindTop3ByStatus                      func(ctx context.Context, status string) ([]UserModel, error)                   `repo:"@Query"`
+	FindDistinctByPartnerId               func(ctx context.Context, partnerId string) ([]UserModel, error)                `repo:"@Query"`
+	FindAllByStatus                       func(ctx context.Context, status string, limit repo.Limit) ([]UserModel, error) `repo:"@Query"`
+
+	// Stream kết quả lớn, không nạp hết vào bộ nhớ
+	FindAllByPartnerIdOrderByCreatedAt func(ctx context.Context, partnerId string) iter.Seq2[UserModel, error] `repo:"@Query"`
+
+	// Tham số tùy chọn: repo.Opt không có giá trị thì bỏ điều kiện tương ứng
+	FindAllByStatusAndPartnerId func(ctx context.Context, status repo.Opt[string], partnerId repo.Opt[string]) ([]UserModel, error) `repo:"@Query"`
+
+	// Aggregate: Count/Sum/Avg/Min/Max kèm điều kiện và GroupBy
+	SumTotalByPartnerId   func(ctx context.Context, partnerId string) (int64, error)   `repo:"@Query"`
+	MaxCreatedAtByStatus  func(ctx context.Context, status string) (*time.Time, error) `repo:"@Query"`
+	CountGroupByStatus    func(ctx context.Context) (map[string]int64, error)          `repo:"@Query"`
+	SumTotalGroupByStatus func(ctx context.Context) ([]StatusTotal, error)             `repo:"@Query"`
+
+	// Câu lệnh có tên trong queries/*.sql, tham số theo thứ tự xuất hiện hoặc một struct/map
+	FindActiveUsers     func(ctx context.Context, since time.Time) ([]UserModel, error)  `repo:"@Query(name=FindActiveUsers)"`
+	CountUsersByPartner func(ctx context.Context, status string) ([]PartnerTotal, error) `repo:"@Query(name=CountUsersByPartner)" readonly:"true"`
+	DeactivateUsers     func(ctx context.Context, params DeactivateParams) error         `repo:"@Query(name=DeactivateUsers)" timeout:"2s"`
+
+	// Thủ tục/hàm lưu trữ: tham số IN theo thứ tự, tham số OUT khai báo bằng tag out
+	TransferTotal func(ctx context.Context, from, to uuid.UUID, amount int) (TransferResult, error) `repo:"@Procedure(transfer_total)" out:"status,remaining"`
+	UserBalance   func(ctx context.Context, id uuid.UUID) (int64, error)                            `repo:"@Function(user_balance)"`
+
+	// Timeout riêng, đọc từ replica (readonly) và hint cho bộ tối ưu theo dialect
+	FindAllByPartnerIdAndStatus func(ctx context.Context, partnerId string, status string) ([]UserModel, error) `repo:"@Query" readonly:"true" timeout:"500ms" hint_mysql:"MAX_EXECUTION_TIME(500)" hint_postgres:"IndexScan(user_tbl)"`
+}
+
+// TransferResult các tham số OUT của thủ tục transfer_total
+type TransferResult struct {
+	Status    string
+	Remaining int
+}
+
+// PartnerTotal kết quả CountUsersByPartner
+type PartnerTotal struct {
+	PartnerId string
+	Total     int64
+}
+
+// DeactivateParams tham số DeactivateUsers, field khớp tên tham số :partner_id, :before
+type DeactivateParams struct {
+	PartnerId string
+	Before    time.Time
+}
+
+//go:generate go run ./repogen -type UserStore
+
+// UserStore khai báo repository bằng interface, phần cài đặt (NewUserStore) sinh bởi cmd/repogen
+type UserStore interface {
+	repo.IRepository[UserModel, uuid.UUID]
+	FindByEmail(ctx context.Context, email string) (*UserModel, error)
+	//repo:tag optional:"true"
+	FindAllByStatusAndPartnerIdOrderByCreatedAtDesc(ctx context.Context, status *string, partnerId *string) ([]UserModel, error)
+	CountByStatus(ctx context.Context, status string) (int64, error)
+}
+
+// StatusTotal kết quả SumTotalGroupByStatus
+type StatusTotal struct {
+	Status   string
+	SumTotal int64
 }
 
 func main() {
@@ -88,19 +151,40 @@ func main() {
 		SSLMode:  "disable",
 		Driver:   "postgres",
 	})
+	// Replica chỉ đọc cho các hàm readonly và DataSource.ReadOnly:
+	//db.Open(cfg, db.WithReplicas(postgres.Open("host=replica1 ..."), postgres.Open("host=replica2 ...")))
 
-	repository := repo.NewRepository[UserModel, uuid.UUID](datab)
-	r := &UserRepository{
-		Repository: repository,
+	queries, err := repo.LoadQueries(queryFiles)
+	if err != nil {
+		panic(err)
 	}
-
-	err := r.Repository.FillFuncFields(r)
+	repository := repo.NewRepository[UserModel, uuid.UUID](datab, repo.WithQueries(queries))
+	// NewUserRepository sinh bởi cmd/repogen (go generate), không dùng reflection khi gọi.
+	// Cách cũ vẫn dùng được: r := &UserRepository{Repository: repository}; err := repository.FillFuncFields(r)
+	r, err := NewUserRepository(repository)
 	if err != nil {
 		panic(err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
+	// Người thực hiện cho các field @CreatedBy/@LastModifiedBy (thường gắn trong middleware xác thực)
+	ctx = db.WithAuditor(ctx, "system")
+
+	// go run ./cmd explain [-analyze] [method [args...]]: xem SQL/EXPLAIN của các hàm dynamic
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		if err := explain(ctx, repository, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Cảnh báo (log của gorm) các hàm dynamic lọc/sắp xếp trên cột chưa có index;
+	// ở staging có thể dùng repo.FailOnMissingIndex() để dừng khởi động
+	if _, err := repository.CheckIndexes(ctx); err != nil {
+		fmt.Println(err)
+	}
 
 	user3, err := repository.FindByID(ctx, uuid.MustParse("78c83478-5e15-4720-9acb-b70ab32f011b"))
 	fmt.Println(user3, err)
@@ -144,6 +228,77 @@ func main() {
 	userNotNull, err := r.FindByCreatedAtIsNotNull(ctx)
 	fmt.Println("FindByCreatedAtIsNotNull:", userNotNull, err)
 
+	// First/TopN/Distinct
+	userFirst, err := r.FindFirstByStatusOrderByCreatedAtDesc(ctx, "active")
+	fmt.Println("FindFirstByStatusOrderByCreatedAtDesc:", userFirst, err)
+
+	usersTop, err := r.FindTop3ByStatus(ctx, "active")
+	fmt.Println("FindTop3ByStatus:", usersTop, err)
+
+	usersDistinct, err := r.FindDistinctByPartnerId(ctx, "partner-1")
+	fmt.Println("FindDistinctByPartnerId:", usersDistinct, err)
+
+	usersPage, err := r.FindAllByStatus(ctx, "active", repo.Limit(20))
+	fmt.Println("FindAllByStatus:", usersPage, err)
+
+	for user, err := range r.FindAllByPartnerIdOrderByCreatedAt(ctx, "partner-1") {
+		if err != nil {
+			fmt.Println("FindAllByPartnerIdOrderByCreatedAt:", err)
+			break
+		}
+		fmt.Println("FindAllByPartnerIdOrderByCreatedAt:", user.ID)
+	}
+
+	usersFiltered, err := r.FindAllByStatusAndPartnerId(ctx, repo.Some("active"), repo.None[string]())
+	fmt.Println("FindAllByStatusAndPartnerId:", usersFiltered, err)
+
+	partnerTotal, err := r.SumTotalByPartnerId(ctx, "partner-1")
+	fmt.Println("SumTotalByPartnerId:", partnerTotal, err)
+
+	lastActive, err := r.MaxCreatedAtByStatus(ctx, "active")
+	fmt.Println("MaxCreatedAtByStatus:", lastActive, err)
+
+	countByStatus, err := r.CountGroupByStatus(ctx)
+	fmt.Println("CountGroupByStatus:", countByStatus, err)
+
+	totals, err := r.SumTotalGroupByStatus(ctx)
+	fmt.Println("SumTotalGroupByStatus:", totals, err)
+
+	activeUsers, err := r.FindActiveUsers(ctx, time.Now().AddDate(0, -1, 0))
+	fmt.Println("FindActiveUsers:", activeUsers, err)
+
+	partnerTotals, err := r.CountUsersByPartner(ctx, "active")
+	fmt.Println("CountUsersByPartner:", partnerTotals, err)
+
+	err = r.DeactivateUsers(ctx, DeactivateParams{PartnerId: "partner-1", Before: time.Now().AddDate(-1, 0, 0)})
+	fmt.Println("DeactivateUsers:", err)
+
+	transfer, err := r.TransferTotal(ctx, uuid.New(), uuid.New(), 10)
+	fmt.Println("TransferTotal:", transfer, err)
+
+	balance, err := r.UserBalance(ctx, uuid.MustParse("78c83478-5e15-4720-9acb-b70ab32f011b"))
+	fmt.Println("UserBalance:", balance, err)
+
+	// Repository khai báo bằng interface: dễ mock, không gán đè được hàm
+	store, err := NewUserStore(repository)
+	if err != nil {
+		panic(err)
+	}
+	userByEmail, err := store.FindByEmail(ctx, "test@example.com")
+	fmt.Println("UserStore.FindByEmail:", userByEmail, err)
+
+	active := "active"
+	usersActive, err := store.FindAllByStatusAndPartnerIdOrderByCreatedAtDesc(ctx, &active, nil)
+	fmt.Println("UserStore.FindAllByStatusAndPartnerIdOrderByCreatedAtDesc:", usersActive, err)
+
+	activeCount, err := store.CountByStatus(ctx, "active")
+	fmt.Println("UserStore.CountByStatus:", activeCount, err)
+
+	// Query by Example: các field khác zero của probe là điều kiện
+	usersByExample, err := repository.FindByExample(ctx, &UserModel{Status: "active", UserName: "john"},
+		repo.MatchField("UserName", repo.MatchContains), repo.IgnoreCase())
+	fmt.Println("FindByExample:", usersByExample, err)
+
 	// (GormDB chưa khởi tạo nên ví dụ này chỉ minh họa)
 	fmt.Println("Repository methods injected successfully.")
 }
@@ -0,0 +1,18 @@
+-- name: FindActiveUsers
+SELECT * FROM user_tbl
+WHERE status = 'active' AND created_at > :since
+ORDER BY created_at DESC
+
+-- name: FindActiveUsers
+-- dialect: postgres
+SELECT * FROM user_tbl
+WHERE status = 'active' AND created_at > :since::timestamptz
+ORDER BY created_at DESC
+
+-- name: CountUsersByPartner
+SELECT partner_id, count(*) AS total FROM user_tbl
+WHERE status = :status
+GROUP BY partner_id
+
+-- name: DeactivateUsers
+UPDATE user_tbl SET status = 'inactive' WHERE partner_id = :partner_id AND updated_at < :before
@@ -0,0 +1,584 @@
+// Command repogen sinh code khởi tạo repository từ các field `repo:"@Query"`, thay cho FillFuncFields:
+// các hàm được gọi trực tiếp qua repo.Finder (không qua reflect.MakeFunc), tham số truyền bằng repo.Args
+// dựng sẵn theo kiểu khai báo (repo.Opt được tách ngay, không qua ...any). Tên hàm được resolve với schema
+// gorm của entity ngay khi sinh code (sai cú pháp, field/quan hệ không tồn tại, preload sai), sai kiểu
+// tham số/kết quả báo khi biên dịch; riêng lock theo dialect được kiểm tra khi gọi hàm khởi tạo.
+// Để resolve, repogen chạy `go run` một chương trình tạm chứa khai báo entity trong thư mục của package.
+// Field `repo:"@Query(name=X)"` gọi Repository.NamedQuery (hoặc NamedExec khi chỉ trả về error),
+// `repo:"@Procedure(name)"`/`repo:"@Function(name)"` gọi Repository.CallProcedure/CallFunction.
+//
+// Cách dùng (đặt trong file khai báo struct repository):
+//
+//	//go:generate go run github.com/xhkzeroone/go-database/cmd/repogen -type UserRepository
+//
+// -type cũng nhận interface nhúng repo.IRepository[T, ID] (và các interface tùy chọn như repo.SoftDeleteRepository[T, ID]):
+// mỗi method khai báo thêm là một hàm dynamic, repogen sinh struct cài đặt và hàm khởi tạo trả về interface.
+// Tag của method (preload, lock, optional...) đặt trong comment //repo:tag ngay trên method:
+//
+//	type UserStore interface {
+//		repo.IRepository[UserModel, uuid.UUID]
+//		//repo:tag preload:"Partner"
+//		FindByEmail(ctx context.Context, email string) (*UserModel, error)
+//	}
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/xhkzeroone/go-database/repo"
+)
+
+const (
+	repoImportPath = "github.com/xhkzeroone/go-database/repo"
+	dbImportPath   = "github.com/xhkzeroone/go-database/db"
+)
+
+// optionalInterfaces các interface tùy chọn của repo được nhúng thêm vào interface repository,
+// *repo.Repository[T, ID] đã cài đặt sẵn nên struct sinh ra không cần thêm method
+var optionalInterfaces = []string{"SoftDeleteRepository", "HistoryRepository", "StreamRepository", "ExampleRepository", "NamedQueryRepository", "ProcedureRepository"}
+
+func main() {
+	typeName := flag.String("type", "", "tên struct repository (bắt buộc)")
+	output := flag.String("output", "", "file sinh ra, mặc định <type>_repogen.go")
+	constructor := flag.String("constructor", "", "tên hàm khởi tạo, mặc định New<type>")
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_repogen.go"
+	}
+	if *constructor == "" {
+		*constructor = "New" + *typeName
+	}
+	if err := run(".", *typeName, *output, *constructor); err != nil {
+		fmt.Fprintln(os.Stderr, "repogen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, typeName, output, constructor string) error {
+	fset := token.NewFileSet()
+	files, file, spec, err := findType(fset, dir, typeName, output)
+	if err != nil {
+		return err
+	}
+	g := &generator{fset: fset, imports: map[string]string{}, used: map[string]bool{}}
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := filepath.Base(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		g.imports[name] = path
+		if path == repoImportPath {
+			g.repo = name
+		}
+	}
+	if g.repo == "" {
+		return fmt.Errorf("file khai báo %s không import %s", typeName, repoImportPath)
+	}
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		err = g.generate(file.Name.Name, typeName, constructor, t)
+	case *ast.InterfaceType:
+		err = g.generateInterface(file.Name.Name, typeName, constructor, t)
+	default:
+		err = fmt.Errorf("%s không phải struct hoặc interface", typeName)
+	}
+	if err != nil {
+		return err
+	}
+	if err := g.checkSchema(dir, typeName, files); err != nil {
+		return err
+	}
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format code sinh ra: %w\n%s", err, g.buf.String())
+	}
+	return os.WriteFile(filepath.Join(dir, output), src, 0o644)
+}
+
+// findType tìm khai báo typeName trong các file .go của dir (bỏ qua file test và file sinh ra),
+// trả về cả các file đã parse của package
+func findType(fset *token.FileSet, dir, typeName, output string) ([]*ast.File, *ast.File, *ast.TypeSpec, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var files []*ast.File
+	var found *ast.File
+	var spec *ast.TypeSpec
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == output {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		files = append(files, file)
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gen.Specs {
+				if ts := s.(*ast.TypeSpec); ts.Name.Name == typeName && spec == nil {
+					found, spec = file, ts
+				}
+			}
+		}
+	}
+	if spec == nil {
+		return nil, nil, nil, fmt.Errorf("không tìm thấy type %s trong %s", typeName, dir)
+	}
+	return files, found, spec, nil
+}
+
+type generator struct {
+	fset    *token.FileSet
+	buf     bytes.Buffer
+	repo    string            // tên package repo trong file nguồn
+	imports map[string]string // tên package -> import path của file nguồn
+	used    map[string]bool   // các package được code sinh ra dùng tới
+
+	typeArgs []ast.Expr    // [T, ID] của repository
+	checks   []schemaCheck // các hàm Finder cần resolve với schema của T
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// typeString in biểu thức kiểu và ghi nhận các package được dùng
+func (g *generator) typeString(expr ast.Expr) string {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				g.used[id.Name] = true
+			}
+		}
+		return true
+	})
+	var b bytes.Buffer
+	_ = printer.Fprint(&b, g.fset, expr)
+	return b.String()
+}
+
+// isRepoType kiểu là repo.<name> (có thể kèm tham số kiểu)
+func (g *generator) isRepoType(expr ast.Expr, name string) bool {
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == g.repo
+}
+
+func (g *generator) generate(pkg, typeName, constructor string, st *ast.StructType) error {
+	var body bytes.Buffer
+	var baseField, baseType string
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			if star, ok := field.Type.(*ast.StarExpr); ok && g.isRepoType(star.X, "Repository") {
+				baseField, baseType = "Repository", g.typeString(field.Type)
+				if index, ok := star.X.(*ast.IndexListExpr); ok {
+					g.typeArgs = index.Indices
+				}
+			}
+			continue
+		}
+		if field.Tag == nil {
+			continue
+		}
+		tagValue, _ := strconv.Unquote(field.Tag.Value)
+		tag := reflect.StructTag(tagValue)
+		d, err := directCall(tag)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", typeName, field.Names[0].Name, err)
+		}
+		if d == nil && tag.Get("repo") != "@Query" {
+			continue
+		}
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			return fmt.Errorf("%s.%s: kiểu %s không được hỗ trợ, khai báo field dạng func(ctx context.Context, ...) (...)",
+				typeName, field.Names[0].Name, g.typeString(field.Type))
+		}
+		for _, name := range field.Names {
+			if d != nil {
+				signature, call, err := g.method(name.Name, tag, fn, d, "base")
+				if err != nil {
+					return fmt.Errorf("%s.%s: %w", typeName, name.Name, err)
+				}
+				fmt.Fprintf(&body, "\nr.%s = func%s {\n%s\n}\n", name.Name, signature, call)
+				continue
+			}
+			g.checks = append(g.checks, schemaCheck{name: name.Name, tag: tagValue})
+			finder := lowerFirst(name.Name) + "Finder"
+			signature, call, err := g.method(name.Name, tag, fn, nil, finder)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", typeName, name.Name, err)
+			}
+			fmt.Fprintf(&body, `
+%s, err := base.Finder(%q, %s)
+if err != nil {
+	return nil, err
+}
+r.%s = func%s {
+	%s
+}
+`, finder, name.Name, field.Tag.Value, name.Name, signature, call)
+		}
+	}
+	if baseField == "" {
+		return fmt.Errorf("%s phải nhúng *%s.Repository[T, ID]", typeName, g.repo)
+	}
+
+	g.header(pkg)
+	g.printf("// %s tạo %s với các hàm @Query gọi trực tiếp %s.Finder, không qua reflection\n", constructor, typeName, g.repo)
+	g.printf("func %s(base %s) (*%s, error) {\n", constructor, baseType, typeName)
+	g.printf("r := &%s{%s: base}\n", typeName, baseField)
+	g.buf.Write(body.Bytes())
+	g.printf("return r, nil\n}\n")
+	return nil
+}
+
+// generateInterface sinh struct cài đặt interface typeName: nhúng *repo.Repository[T, ID] cho các method
+// của repo.IRepository, mỗi method khai báo thêm gọi một repo.Finder tạo sẵn trong hàm khởi tạo
+func (g *generator) generateInterface(pkg, typeName, constructor string, it *ast.InterfaceType) error {
+	impl := lowerFirst(typeName) + "Impl"
+	var typeArgs string
+	var finders []string
+	var inits, methods bytes.Buffer
+	for _, field := range it.Methods.List {
+		if len(field.Names) == 0 {
+			if slices.ContainsFunc(optionalInterfaces, func(name string) bool { return g.isRepoType(field.Type, name) }) {
+				continue
+			}
+			if !g.isRepoType(field.Type, "IRepository") {
+				return fmt.Errorf("%s chỉ được nhúng %s.IRepository[T, ID] và %s.%s", typeName, g.repo, g.repo,
+					strings.Join(optionalInterfaces, ", "+g.repo+"."))
+			}
+			index, ok := field.Type.(*ast.IndexListExpr)
+			if !ok {
+				return fmt.Errorf("%s.IRepository cần tham số kiểu [T, ID]", g.repo)
+			}
+			args := make([]string, len(index.Indices))
+			for i, arg := range index.Indices {
+				args[i] = g.typeString(arg)
+			}
+			typeArgs = "[" + strings.Join(args, ", ") + "]"
+			g.typeArgs = index.Indices
+			continue
+		}
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			continue
+		}
+		tag := methodTag(field.Doc)
+		name := field.Names[0].Name
+		d, err := directCall(reflect.StructTag(tag))
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", typeName, name, err)
+		}
+		target := "r"
+		if d == nil {
+			target = "r." + lowerFirst(name)
+		}
+		signature, call, err := g.method(name, reflect.StructTag(tag), fn, d, target)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", typeName, name, err)
+		}
+		fmt.Fprintf(&methods, "\nfunc (r *%s) %s%s {\n%s\n}\n", impl, name, signature, call)
+		if d != nil {
+			continue
+		}
+		g.checks = append(g.checks, schemaCheck{name: name, tag: tag})
+		finder := lowerFirst(name)
+		finders = append(finders, finder)
+		fmt.Fprintf(&inits, "if r.%s, err = base.Finder(%q, %s); err != nil {\nreturn nil, err\n}\n", finder, name, tagLiteral(tag))
+	}
+	if typeArgs == "" {
+		return fmt.Errorf("%s phải nhúng %s.IRepository[T, ID]", typeName, g.repo)
+	}
+	g.used[g.repo] = true
+
+	g.header(pkg)
+	g.printf("// %s cài đặt %s, các method dynamic gọi trực tiếp %s.Finder\n", impl, typeName, g.repo)
+	g.printf("type %s struct {\n*%s.Repository%s\n", impl, g.repo, typeArgs)
+	for _, finder := range finders {
+		g.printf("%s *%s.Finder%s\n", finder, g.repo, typeArgs)
+	}
+	g.printf("}\n\nvar _ %s = (*%s)(nil)\n\n", typeName, impl)
+	g.printf("// %s tạo %s từ repository gốc, lỗi khi tên method không hợp lệ với entity\n", constructor, typeName)
+	g.printf("func %s(base *%s.Repository%s) (%s, error) {\n", constructor, g.repo, typeArgs, typeName)
+	g.printf("r := &%s{Repository: base}\nvar err error\n", impl)
+	g.buf.Write(inits.Bytes())
+	g.printf("return r, nil\n}\n")
+	g.buf.Write(methods.Bytes())
+	return nil
+}
+
+// header ghi phần đầu file sinh ra: package và các import được dùng
+func (g *generator) header(pkg string) {
+	g.printf("// Code generated by repogen; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	names := make([]string, 0, len(g.used))
+	for name := range g.used {
+		if _, ok := g.imports[name]; ok {
+			names = append(names, name)
+		}
+	}
+	// Thư viện chuẩn trước, package ngoài sau
+	sort.Slice(names, func(i, j int) bool {
+		si, sj := isStdlib(g.imports[names[i]]), isStdlib(g.imports[names[j]])
+		if si != sj {
+			return si
+		}
+		return g.imports[names[i]] < g.imports[names[j]]
+	})
+	g.printf("import (\n")
+	for i, name := range names {
+		path := g.imports[name]
+		if i > 0 && isStdlib(g.imports[names[i-1]]) && !isStdlib(path) {
+			g.printf("\n")
+		}
+		if filepath.Base(path) == name {
+			g.printf("\t%q\n", path)
+		} else {
+			g.printf("\t%s %q\n", name, path)
+		}
+	}
+	g.printf(")\n\n")
+}
+
+// method sinh chữ ký "(tham số) kết quả" và thân hàm của một hàm dynamic: gọi Finder target,
+// hoặc gọi thẳng repository target với d (@Query(name=...), @Procedure, @Function)
+func (g *generator) method(name string, tag reflect.StructTag, fn *ast.FuncType, d *direct, target string) (signature, call string, err error) {
+	// Kiểm tra cú pháp tên hàm và tag bằng cùng bộ phân tích với FillFuncFields, tên field được resolve sau
+	// với schema của entity (checkSchema)
+	if d == nil {
+		if _, err := repo.NewRepository[struct{}, int](nil).Finder(name, tag); err != nil {
+			return "", "", err
+		}
+	}
+	optional := tag.Get("optional") == "true" && d == nil
+
+	var params, args []string
+	var values, present, prelude []string // Args của Finder: repo.Opt được tách ngay trong code sinh ra
+	hasOptional := false
+	limit := "0"
+	for _, field := range fn.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return "", "", fmt.Errorf("không hỗ trợ tham số variadic")
+		}
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for j := 0; j < n; j++ {
+			typ := g.typeString(field.Type)
+			if len(params) == 0 {
+				if typ != "context.Context" {
+					return "", "", fmt.Errorf("tham số đầu tiên phải là context.Context")
+				}
+				params = append(params, "ctx context.Context")
+				continue
+			}
+			arg := fmt.Sprintf("p%d", len(params))
+			params = append(params, arg+" "+typ)
+			if g.isRepoType(field.Type, "Limit") {
+				limit = arg
+				continue
+			}
+			args = append(args, arg)
+			switch _, isPtr := field.Type.(*ast.StarExpr); {
+			case d == nil && (g.isRepoType(field.Type, "Opt") || (optional && isPtr)):
+				value, ok := fmt.Sprintf("v%d", len(params)-1), fmt.Sprintf("ok%d", len(params)-1)
+				if isPtr {
+					arg = fmt.Sprintf("%s.OptOf(%s)", g.repo, arg)
+				}
+				prelude = append(prelude, fmt.Sprintf("%s, %s := %s.Get()\n", value, ok, arg))
+				values, present, hasOptional = append(values, value), append(present, ok), true
+			default:
+				values, present = append(values, arg), append(present, "true")
+			}
+		}
+	}
+	if len(params) == 0 {
+		return "", "", fmt.Errorf("tham số đầu tiên phải là context.Context")
+	}
+
+	var results []string
+	if fn.Results != nil {
+		for _, field := range fn.Results.List {
+			for j := 0; j < max(len(field.Names), 1); j++ {
+				results = append(results, g.typeString(field.Type))
+			}
+		}
+	}
+	resultList := strings.Join(results, ", ")
+	if len(results) > 1 {
+		resultList = "(" + resultList + ")"
+	}
+
+	callArgs := strings.Join(append([]string{"ctx"}, args...), ", ")
+	finderArgs := g.repo + ".Args{}"
+	switch {
+	case hasOptional:
+		finderArgs = fmt.Sprintf("%s.Args{Values: []any{%s}, Present: []bool{%s}}", g.repo, strings.Join(values, ", "), strings.Join(present, ", "))
+	case len(values) > 0:
+		finderArgs = fmt.Sprintf("%s.Args{Values: []any{%s}}", g.repo, strings.Join(values, ", "))
+	}
+	// Timeout/readonly của lời gọi thẳng: bọc trong Repository.Call (Finder tự đọc từ tag)
+	wrap := func(body string) string {
+		return fmt.Sprintf("%s.Call(ctx, %s, func(ctx context.Context) error {\n%s\n})", target, tagLiteral(string(tag)), body)
+	}
+	switch {
+	case d != nil && limit != "0":
+		return "", "", fmt.Errorf("%s không nhận repo.Limit", d.kind)
+	case d != nil && len(results) == 1 && results[0] == "error" && d.kind == "@Query":
+		call = fmt.Sprintf("_, err := %s.NamedExec(ctx, %q%s)\nreturn err", target, d.name, strings.TrimPrefix(callArgs, "ctx"))
+		if d.wrap {
+			call = "return " + wrap(call)
+		}
+	case d != nil && len(results) == 1 && results[0] == "error" && d.kind == "@Procedure" && len(d.out) == 0:
+		stmt := fmt.Sprintf("%s.CallProcedure(ctx, %q, nil%s)", target, d.name, strings.TrimPrefix(callArgs, "ctx"))
+		if d.wrap {
+			stmt = wrap("return " + stmt)
+		}
+		call = "return " + stmt
+	case d != nil && len(results) == 2:
+		for _, out := range d.out {
+			callArgs += fmt.Sprintf(", %s.Out(%q)", g.repo, out)
+		}
+		stmt := fmt.Sprintf("%s.%s(ctx, %q, &out%s)", target, d.method, d.name, strings.TrimPrefix(callArgs, "ctx"))
+		if d.wrap {
+			stmt = wrap("return " + stmt)
+		}
+		call = fmt.Sprintf("var out %s\nerr := %s\nreturn out, err", results[0], stmt)
+	case d != nil:
+		return "", "", fmt.Errorf("%s phải trả về (result, error) hoặc error", d.kind)
+	case repo.MethodKind(name) == repo.PlanAggregate:
+		if len(results) != 2 || limit != "0" {
+			return "", "", fmt.Errorf("hàm aggregate phải trả về (result, error) và không nhận repo.Limit")
+		}
+		call = fmt.Sprintf("var out %s\nerr := %s.AggregateArgs(ctx, &out, %s)\nreturn out, err", results[0], target, finderArgs)
+	case len(results) == 1 && strings.HasPrefix(results[0], "iter.Seq2["):
+		call = fmt.Sprintf("return %s.StreamArgs(ctx, %s, %s)", target, limit, finderArgs)
+	case len(results) == 2 && strings.HasPrefix(results[0], "[]"):
+		call = fmt.Sprintf("return %s.AllArgs(ctx, %s, %s)", target, limit, finderArgs)
+	case len(results) == 2 && strings.HasPrefix(results[0], "*") && !strings.HasPrefix(name, "FindAll"):
+		call = fmt.Sprintf("return %s.OneArgs(ctx, %s)", target, finderArgs)
+	default:
+		return "", "", fmt.Errorf("kiểu trả về phải là (*T, error), ([]T, error), iter.Seq2[T, error] hoặc (result, error) với hàm aggregate")
+	}
+	if d == nil {
+		call = strings.Join(prelude, "") + call
+	}
+
+	return "(" + strings.Join(params, ", ") + ") " + resultList, call, nil
+}
+
+// direct lời gọi thẳng Repository, không qua Finder
+type direct struct {
+	kind   string // @Query, @Procedure, @Function
+	method string // NamedQuery, CallProcedure, CallFunction
+	name   string
+	out    []string // tham số OUT của @Procedure (tag out)
+	wrap   bool     // có tag timeout/readonly, gọi qua Repository.Call
+}
+
+// directCall đọc tag `repo:"@Query(name=X)"`, `repo:"@Procedure(name)"`, `repo:"@Function(name)"`,
+// nil với `repo:"@Query"` (Finder) và các tag khác
+func directCall(tag reflect.StructTag) (*direct, error) {
+	value := tag.Get("repo")
+	for _, d := range []direct{{kind: "@Query", method: "NamedQuery"}, {kind: "@Procedure", method: "CallProcedure"}, {kind: "@Function", method: "CallFunction"}} {
+		arg, ok := strings.CutPrefix(value, d.kind+"(")
+		if !ok {
+			continue
+		}
+		arg, ok = strings.CutSuffix(arg, ")")
+		name, hasName := strings.CutPrefix(strings.TrimSpace(arg), "name=")
+		if d.name = strings.TrimSpace(name); !ok || d.name == "" || (d.kind == "@Query" && !hasName) {
+			return nil, fmt.Errorf("tag %q không hợp lệ, dùng @Query(name=X), @Procedure(name) hoặc @Function(name)", value)
+		}
+		if out := tag.Get("out"); out != "" {
+			if d.kind != "@Procedure" {
+				return nil, fmt.Errorf("tag out chỉ dùng với @Procedure")
+			}
+			for _, name := range strings.Split(out, ",") {
+				d.out = append(d.out, strings.TrimSpace(name))
+			}
+		}
+		if timeout := tag.Get("timeout"); timeout != "" {
+			if t, err := time.ParseDuration(timeout); err != nil || t <= 0 {
+				return nil, fmt.Errorf("tag timeout %q không hợp lệ, dùng dạng 500ms, 2s", timeout)
+			}
+			d.wrap = true
+		}
+		if readonly := tag.Get("readonly"); readonly != "" {
+			if _, err := strconv.ParseBool(readonly); err != nil {
+				return nil, fmt.Errorf("tag readonly %q không hợp lệ (true|false)", readonly)
+			}
+			d.wrap = true
+		}
+		return &d, nil
+	}
+	return nil, nil
+}
+
+// methodTag tag của method interface, đọc từ comment //repo:tag
+func methodTag(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	for _, c := range doc.List {
+		if tag, ok := strings.CutPrefix(c.Text, "//repo:tag "); ok {
+			return strings.TrimSpace(tag)
+		}
+	}
+	return ""
+}
+
+// tagLiteral literal Go của tag
+func tagLiteral(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+func isStdlib(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "ghi lại các file .golden trong testdata")
+
+// moduleDir thư mục tạm trong testdata: nằm trong module để chương trình kiểm tra schema import được repo
+func moduleDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("testdata", "run-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// copyPackage chép các file .go của testdata/<name> vào thư mục tạm
+func copyPackage(t *testing.T, name string) string {
+	t.Helper()
+	dir := moduleDir(t)
+	paths, err := filepath.Glob(filepath.Join("testdata", name, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(path)), src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRunGolden(t *testing.T) {
+	tests := []struct {
+		typeName, output, constructor string
+	}{
+		{"AccountRepository", "accountrepository_repogen.go", "NewAccountRepository"},
+		{"AccountStore", "store_gen.go", "OpenAccountStore"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typeName, func(t *testing.T) {
+			dir := copyPackage(t, "store")
+			if err := run(dir, tt.typeName, tt.output, tt.constructor); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(filepath.Join(dir, tt.output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "store", tt.output+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("%s khác %s (chạy go test -update để cập nhật):\n%s", tt.output, golden, got)
+			}
+
+			// Chạy lại khi đã có file sinh ra: file đó bị bỏ qua khi tìm type
+			if err := run(dir, tt.typeName, tt.output, tt.constructor); err != nil {
+				t.Fatalf("chạy lại: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	const header = `package store
+
+import (
+	"context"
+
+	"github.com/xhkzeroone/go-database/repo"
+)
+
+type Account struct{ ID int }
+`
+	tests := []struct {
+		name, src, typeName, err string
+	}{
+		{"không có type", "", "Missing", "không tìm thấy type Missing"},
+		{"không import repo", "package store\ntype S struct{}\n", "S", "không import github.com/xhkzeroone/go-database/repo"},
+		{"không phải struct", "type S int\nvar _ repo.Limit\n", "S", "không phải struct hoặc interface"},
+		{"thiếu Repository", "type S struct {\n\tFindById func(ctx context.Context, id int) (*Account, error) `repo:\"@Query\"`\n}\n",
+			"S", "phải nhúng *repo.Repository[T, ID]"},
+		{"tên hàm sai", "type S struct {\n\t*repo.Repository[Account, int]\n\tGetById func(ctx context.Context, id int) (*Account, error) `repo:\"@Query\"`\n}\n",
+			"S", "S.GetById: method GetById"},
+		{"thiếu context", "type S struct {\n\t*repo.Repository[Account, int]\n\tFindById func(id int) (*Account, error) `repo:\"@Query\"`\n}\n",
+			"S", "tham số đầu tiên phải là context.Context"},
+		{"variadic", "type S struct {\n\t*repo.Repository[Account, int]\n\tFindByIdIn func(ctx context.Context, ids ...int) ([]Account, error) `repo:\"@Query\"`\n}\n",
+			"S", "không hỗ trợ tham số variadic"},
+		{"kiểu trả về", "type S struct {\n\t*repo.Repository[Account, int]\n\tFindAllById func(ctx context.Context, id int) (*Account, error) `repo:\"@Query\"`\n}\n",
+			"S", "kiểu trả về phải là"},
+		{"aggregate nhận Limit", "type S struct {\n\t*repo.Repository[Account, int]\n\tCountById func(ctx context.Context, id int, limit repo.Limit) (int64, error) `repo:\"@Query\"`\n}\n",
+			"S", "không nhận repo.Limit"},
+		{"procedure nhận Limit", "type S struct {\n\t*repo.Repository[Account, int]\n\tArchive func(ctx context.Context, limit repo.Limit) error `repo:\"@Procedure(archive)\"`\n}\n",
+			"S", "@Procedure không nhận repo.Limit"},
+		{"interface nhúng lạ", "type S interface {\n\trepo.IRepository[Account, int]\n\tcontext.Context\n}\n",
+			"S", "chỉ được nhúng repo.IRepository[T, ID]"},
+		{"interface thiếu IRepository", "type S interface {\n\tFindById(ctx context.Context, id int) (*Account, error)\n}\n",
+			"S", "phải nhúng repo.IRepository[T, ID]"},
+		{"kiểu func có tên", "type Finder func(ctx context.Context, id int) (*Account, error)\n" +
+			"type S struct {\n\t*repo.Repository[Account, int]\n\tFindById Finder `repo:\"@Query\"`\n}\n",
+			"S", "S.FindById: kiểu Finder không được hỗ trợ"},
+		{"field không tồn tại", "type S struct {\n\t*repo.Repository[Account, int]\n\tFindByStatus func(ctx context.Context, status string) (*Account, error) `repo:\"@Query\"`\n}\n",
+			"S", "S.FindByStatus: method FindByStatus: không tìm thấy field Status trong Account"},
+		{"quan hệ không tồn tại", "type S struct {\n\t*repo.Repository[Account, int]\n\tFindAllByPartnerName func(ctx context.Context, name string) ([]Account, error) `repo:\"@Query\"`\n}\n",
+			"S", "S.FindAllByPartnerName: method FindAllByPartnerName: không tìm thấy field PartnerName trong Account"},
+		{"preload không tồn tại", "type S interface {\n\trepo.IRepository[Account, int]\n\t//repo:tag preload:\"Partner\"\n\tFindById(ctx context.Context, id int) (*Account, error)\n}\n",
+			"S", "S.FindById: method FindById: không tìm thấy quan hệ Partner trong Account"},
+		{"interface tag sai", "type S interface {\n\trepo.IRepository[Account, int]\n\t//repo:tag repo:\"@Function()\"\n\tBalance(ctx context.Context) (int64, error)\n}\n",
+			"S", "S.Balance: tag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := moduleDir(t)
+			src := tt.src
+			if !strings.HasPrefix(src, "package") {
+				src = header + src
+			}
+			if err := os.WriteFile(filepath.Join(dir, "store.go"), []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			err := run(dir, tt.typeName, "out_gen.go", "New"+tt.typeName)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "out_gen.go")); err == nil {
+				t.Error("không được ghi file khi có lỗi")
+			}
+		})
+	}
+}
+
+func TestDirectCall(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want *direct
+		err  string
+	}{
+		{tag: `repo:"@Query"`},
+		{tag: `repo:"@Version"`},
+		{tag: `repo:"@Query(name=FindActive)"`, want: &direct{kind: "@Query", method: "NamedQuery", name: "FindActive"}},
+		{tag: `repo:"@Query( name=FindActive )"`, want: &direct{kind: "@Query", method: "NamedQuery", name: "FindActive"}},
+		{tag: `repo:"@Procedure(transfer)" out:"status, remaining"`,
+			want: &direct{kind: "@Procedure", method: "CallProcedure", name: "transfer", out: []string{"status", "remaining"}}},
+		{tag: `repo:"@Function(balance)" timeout:"1s"`, want: &direct{kind: "@Function", method: "CallFunction", name: "balance", wrap: true}},
+		{tag: `repo:"@Query(name=X)" readonly:"false"`, want: &direct{kind: "@Query", method: "NamedQuery", name: "X", wrap: true}},
+		{tag: `repo:"@Query(FindActive)"`, err: "không hợp lệ"},
+		{tag: `repo:"@Query(name=)"`, err: "không hợp lệ"},
+		{tag: `repo:"@Procedure(transfer"`, err: "không hợp lệ"},
+		{tag: `repo:"@Function(balance)" out:"x"`, err: "tag out chỉ dùng với @Procedure"},
+		{tag: `repo:"@Function(balance)" timeout:"-1s"`, err: "tag timeout"},
+		{tag: `repo:"@Function(balance)" readonly:"yes"`, err: "tag readonly"},
+	}
+	for _, tt := range tests {
+		d, err := directCall(tt.tag)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: lỗi = %v, muốn chứa %q", tt.tag, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(d, tt.want) {
+			t.Errorf("%s: directCall = %+v, %v; muốn %+v", tt.tag, d, err, tt.want)
+		}
+	}
+}
+
+func TestMethodTag(t *testing.T) {
+	src := `package store
+
+type S interface {
+	A()
+	// B có mô tả
+	B()
+	//repo:tag preload:"Partner"
+	C()
+	// D mô tả trước tag
+	//repo:tag   lock:"update"
+	D()
+	//repo:tagged
+	E()
+}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "store.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"A": "", "B": "", "C": `preload:"Partner"`, "D": `lock:"update"`, "E": ""}
+	it := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.InterfaceType)
+	for _, m := range it.Methods.List {
+		name := m.Names[0].Name
+		if got := methodTag(m.Doc); got != want[name] {
+			t.Errorf("methodTag(%s) = %q, muốn %q", name, got, want[name])
+		}
+	}
+}
+
+func TestTagLiteral(t *testing.T) {
+	if got := tagLiteral(`repo:"@Query"`); got != "`repo:\"@Query\"`" {
+		t.Errorf("tagLiteral = %s", got)
+	}
+	if got := tagLiteral("hint:\"`x`\""); got != `"hint:\"`+"`x`"+`\""` {
+		t.Errorf("tagLiteral có backquote = %s", got)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// schemaCheck một hàm @Query (tên field/method và tag) cần resolve với schema của entity
+type schemaCheck struct {
+	name, tag string
+}
+
+// schemaMarker tiền tố dòng lỗi chương trình kiểm tra in ra, phân biệt với lỗi biên dịch
+const schemaMarker = "repogen-schema\t"
+
+// checkSchema resolve tên các hàm @Query (property, JOIN, preload) với schema gorm thật của entity.
+// Khai báo entity cùng các type, hàm, biến nó dùng tới được chép vào một chương trình tạm trong dir
+// (để import theo module của package), chương trình này gọi repo.Finder với gorm DryRun.
+func (g *generator) checkSchema(dir, typeName string, files []*ast.File) error {
+	if len(g.checks) == 0 {
+		return nil
+	}
+	probe, err := os.MkdirTemp(dir, ".repogen-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(probe)
+
+	decls := packageDecls(files)
+	roots := make([]ast.Node, len(g.typeArgs))
+	for i, arg := range g.typeArgs {
+		roots[i] = arg
+	}
+	decls.mark(roots...)
+	for _, file := range files {
+		src := decls.closure(g.fset, file)
+		if src == nil {
+			continue
+		}
+		name := filepath.Base(g.fset.File(file.Pos()).Name())
+		if err := os.WriteFile(filepath.Join(probe, name), src, 0o644); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(probe, "repogen_main.go"), g.probeMain(), 0o644); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = probe
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if rest, ok := strings.CutPrefix(line, schemaMarker); ok {
+			name, msg, _ := strings.Cut(rest, "\t")
+			return fmt.Errorf("%s.%s: %s", typeName, name, msg)
+		}
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("kiểm tra schema: %w", err)
+	}
+	return fmt.Errorf("kiểm tra schema của %s: %w\n%s", typeName, err, out)
+}
+
+// probeMain hàm main của chương trình kiểm tra. Import đặt tên riêng để không trùng với khai báo được chép
+func (g *generator) probeMain() []byte {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by repogen; DO NOT EDIT.\n\npackage main\n\nimport (\n")
+	b.WriteString("\trepogenfmt \"fmt\"\n\trepogenos \"os\"\n\trepogenreflect \"reflect\"\n\n")
+	fmt.Fprintf(&b, "\trepogendb %q\n\trepogenrepo %q\n", dbImportPath, repoImportPath)
+	b.WriteString("\trepogengorm \"gorm.io/gorm\"\n\trepogentests \"gorm.io/gorm/utils/tests\"\n")
+	for _, name := range usedImports(g.typeArgs...) {
+		if path, ok := g.imports[name]; ok {
+			fmt.Fprintf(&b, "\t%s %q\n", name, path)
+		}
+	}
+	b.WriteString(")\n\n")
+	b.WriteString("// repogenDialector postgres hỗ trợ mọi lock mode, dialect thật chỉ biết khi chạy\n")
+	b.WriteString("type repogenDialector struct{ repogentests.DummyDialector }\n\n")
+	b.WriteString("func (repogenDialector) Name() string { return \"postgres\" }\n\n")
+	b.WriteString("func main() {\n")
+	b.WriteString("\tgdb, err := repogengorm.Open(repogenDialector{}, &repogengorm.Config{DryRun: true})\n")
+	b.WriteString("\tif err != nil {\n\t\trepogenfmt.Fprintln(repogenos.Stderr, err)\n\t\trepogenos.Exit(2)\n\t}\n")
+	args := make([]string, len(g.typeArgs))
+	for i, arg := range g.typeArgs {
+		args[i] = g.typeString(arg)
+	}
+	fmt.Fprintf(&b, "\tr := repogenrepo.NewRepository[%s](&repogendb.DataSource{DB: gdb})\n", strings.Join(args, ", "))
+	b.WriteString("\tfor _, c := range [][2]string{\n")
+	for _, c := range g.checks {
+		fmt.Fprintf(&b, "\t\t{%q, %q},\n", c.name, c.tag)
+	}
+	b.WriteString("\t} {\n")
+	b.WriteString("\t\tif _, err := r.Finder(c[0], repogenreflect.StructTag(c[1])); err != nil {\n")
+	fmt.Fprintf(&b, "\t\t\trepogenfmt.Fprintf(repogenos.Stderr, \"%%s%%s\\t%%v\\n\", %q, c[0], err)\n", schemaMarker)
+	b.WriteString("\t\t\trepogenos.Exit(1)\n\t\t}\n\t}\n}\n")
+	return b.Bytes()
+}
+
+// declSet các khai báo cấp package theo tên; method được gom theo type nhận
+type declSet struct {
+	byName  map[string][]ast.Decl
+	methods map[string][]*ast.FuncDecl
+	used    map[ast.Decl]bool
+}
+
+func packageDecls(files []*ast.File) *declSet {
+	s := &declSet{byName: map[string][]ast.Decl{}, methods: map[string][]*ast.FuncDecl{}, used: map[ast.Decl]bool{}}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				switch {
+				case d.Recv != nil:
+					recv := receiverName(d.Recv.List[0].Type)
+					s.methods[recv] = append(s.methods[recv], d)
+				case d.Name.Name != "main" && d.Name.Name != "init":
+					s.byName[d.Name.Name] = append(s.byName[d.Name.Name], d)
+				}
+			case *ast.GenDecl:
+				if d.Tok == token.IMPORT {
+					continue
+				}
+				// Cả nhóm const/var/type đi cùng nhau để giữ nguyên iota
+				for _, spec := range d.Specs {
+					switch sp := spec.(type) {
+					case *ast.TypeSpec:
+						s.byName[sp.Name.Name] = append(s.byName[sp.Name.Name], d)
+					case *ast.ValueSpec:
+						for _, name := range sp.Names {
+							s.byName[name.Name] = append(s.byName[name.Name], d)
+						}
+					}
+				}
+			}
+		}
+	}
+	return s
+}
+
+// receiverName tên type của receiver, bỏ * và tham số kiểu
+func receiverName(expr ast.Expr) string {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// mark đánh dấu các khai báo mà các node dùng tới, lan dần qua khai báo được đánh dấu và method của type.
+// Tên field, tham số và phần sau dấu chấm không phải tham chiếu tới khai báo cấp package
+func (s *declSet) mark(nodes ...ast.Node) {
+	var queue []ast.Decl
+	add := func(decl ast.Decl) {
+		if !s.used[decl] {
+			s.used[decl] = true
+			queue = append(queue, decl)
+		}
+	}
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Field:
+			ast.Inspect(n.Type, visit)
+			return false
+		case *ast.FuncDecl:
+			if n.Recv != nil {
+				ast.Inspect(n.Recv, visit)
+			}
+			ast.Inspect(n.Type, visit)
+			if n.Body != nil {
+				ast.Inspect(n.Body, visit)
+			}
+			return false
+		case *ast.Ident:
+			for _, decl := range s.byName[n.Name] {
+				add(decl)
+			}
+			for _, m := range s.methods[n.Name] {
+				add(m)
+			}
+		}
+		return true
+	}
+	for _, n := range nodes {
+		ast.Inspect(n, visit)
+	}
+	for len(queue) > 0 {
+		decl := queue[0]
+		queue = queue[1:]
+		ast.Inspect(decl, visit)
+	}
+}
+
+// closure nguồn Go của các khai báo đã đánh dấu trong file, nil khi không có
+func (s *declSet) closure(fset *token.FileSet, file *ast.File) []byte {
+	var decls []ast.Node
+	for _, decl := range file.Decls {
+		if s.used[decl] {
+			decls = append(decls, decl)
+		}
+	}
+	if len(decls) == 0 {
+		return nil
+	}
+
+	var b bytes.Buffer
+	b.WriteString("package main\n\n")
+	names := usedImports(decls...)
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := importName(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name == "." || slices.Contains(names, name) {
+			fmt.Fprintf(&b, "import %s %q\n", name, path)
+		}
+	}
+	for _, decl := range decls {
+		b.WriteString("\n")
+		_ = printer.Fprint(&b, fset, decl)
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// usedImports tên các package được dùng dạng pkg.X trong các node
+func usedImports[N ast.Node](nodes ...N) []string {
+	var names []string
+	for _, n := range nodes {
+		ast.Inspect(n, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok && !slices.Contains(names, id.Name) {
+					names = append(names, id.Name)
+				}
+			}
+			return true
+		})
+	}
+	return names
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// importName tên package mặc định của import path: phần cuối, bỏ hậu tố phiên bản /vN, .vN và tiền tố go-
+func importName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if majorVersion.MatchString(name) && len(parts) > 1 {
+		name = parts[len(parts)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.ReplaceAll(name, "-", "_")
+}
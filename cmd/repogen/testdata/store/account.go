@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	gouuid "github.com/google/uuid"
+	"github.com/xhkzeroone/go-database/repo"
+	"gorm.io/gorm"
+)
+
+type Account struct {
+	ID        gouuid.UUID `gorm:"primaryKey"`
+	Status    string
+	PartnerId string
+	Partner   *Partner `gorm:"foreignKey:PartnerId"`
+	Total     int64
+	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+func (a Account) String() string {
+	return fmt.Sprintf("%s(%s)", a.ID, a.Status)
+}
+
+type Partner struct {
+	ID   string
+	Name string
+}
+
+type StatusTotal struct {
+	Status   string
+	SumTotal int64
+}
+
+type TransferResult struct {
+	Status    string
+	Remaining int
+}
+
+type AccountRepository struct {
+	*repo.Repository[Account, gouuid.UUID]
+	FindByStatus                      func(ctx context.Context, status string) (*Account, error)                                        `repo:"@Query"`
+	FindByStatusAndPartnerId          func(ctx context.Context, status, partnerId string) (*Account, error)                             `repo:"@Query" preload:"Partner"`
+	FindAllByStatusOrderByTotalDesc   func(ctx context.Context, status string, limit repo.Limit) ([]Account, error)                     `repo:"@Query"`
+	FindAllByCreatedAtBetween         func(ctx context.Context, from, to time.Time) ([]Account, error)                                  `repo:"@Query" timeout:"500ms"`
+	FindAllByPartnerIdOrderByTotal    func(ctx context.Context, partnerId string) iter.Seq2[Account, error]                             `repo:"@Query"`
+	FindAllByStatusAndPartnerId       func(ctx context.Context, status repo.Opt[string], partnerId repo.Opt[string]) ([]Account, error) `repo:"@Query"`
+	FindAllByStatusOrTotalGreaterThan func(ctx context.Context, status *string, total int64) ([]Account, error)                         `repo:"@Query" optional:"true"`
+	FindFirstByDeletedAtIsNull        func(ctx context.Context) (*Account, error)                                                       `repo:"@Query"`
+	CountByStatus                     func(ctx context.Context, status string) (int64, error)                                           `repo:"@Query"`
+	SumTotalGroupByStatus             func(ctx context.Context) ([]StatusTotal, error)                                                  `repo:"@Query" readonly:"true"`
+	MaxCreatedAtByPartnerId           func(ctx context.Context, partnerId repo.Opt[string]) (*time.Time, error)                         `repo:"@Query"`
+
+	FindActive   func(ctx context.Context, since time.Time) ([]Account, error)                         `repo:"@Query(name=FindActive)"`
+	Deactivate   func(ctx context.Context, partnerId string) error                                     `repo:"@Query(name=Deactivate)" timeout:"2s"`
+	Archive      func(ctx context.Context, before time.Time) error                                     `repo:"@Procedure(archive_accounts)"`
+	Transfer     func(ctx context.Context, from, to gouuid.UUID, amount int64) (TransferResult, error) `repo:"@Procedure(transfer)" out:"status,remaining" readonly:"false"`
+	Balance      func(ctx context.Context, id gouuid.UUID) (int64, error)                              `repo:"@Function(account_balance)"`
+	NotGenerated func(ctx context.Context) error
+	alsoIgnored  string `repo:"@Version"`
+}
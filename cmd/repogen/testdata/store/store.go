@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+	"iter"
+	"strings"
+
+	dbrepo "github.com/xhkzeroone/go-database/repo"
+)
+
+var _ = strings.ToUpper
+
+// AccountStore khai báo bằng interface, nhúng thêm interface tùy chọn
+type AccountStore interface {
+	dbrepo.IRepository[Account, string]
+	dbrepo.SoftDeleteRepository[Account, string]
+	dbrepo.StreamRepository[Account, string]
+
+	FindByPartnerId(ctx context.Context, partnerId string) (*Account, error)
+	// FindAllByStatus tài khoản theo trạng thái
+	//repo:tag lock:"update,skip_locked" timeout:"1s"
+	FindAllByStatus(ctx context.Context, status string, limit dbrepo.Limit) ([]Account, error)
+	//repo:tag optional:"true"
+	FindAllByStatusAndTotalBetween(ctx context.Context, status *string, min, max *int64) iter.Seq2[Account, error]
+	AvgTotalByStatus(ctx context.Context, status dbrepo.Opt[string]) (float64, error)
+	// Summary câu lệnh có tên trong file .sql
+	//repo:tag repo:"@Query(name=Summary)" readonly:"true"
+	Summary(ctx context.Context, partnerId string) ([]StatusTotal, error)
+}
@@ -0,0 +1,237 @@
+// Code generated by repogen; DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xhkzeroone/go-database/repo"
+)
+
+// NewUserRepository tạo UserRepository với các hàm @Query gọi trực tiếp repo.Finder, không qua reflection
+func NewUserRepository(base *repo.Repository[UserModel, uuid.UUID]) (*UserRepository, error) {
+	r := &UserRepository{Repository: base}
+
+	findByUserNameFinder, err := base.Finder("FindByUserName", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByUserName = func(ctx context.Context, p1 string) (*UserModel, error) {
+		return findByUserNameFinder.OneArgs(ctx, repo.Args{Values: []any{p1}})
+	}
+
+	findByUserNameAndEmailOrPartnerIdFinder, err := base.Finder("FindByUserNameAndEmailOrPartnerId", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByUserNameAndEmailOrPartnerId = func(ctx context.Context, p1 string, p2 string, p3 string) (*UserModel, error) {
+		return findByUserNameAndEmailOrPartnerIdFinder.OneArgs(ctx, repo.Args{Values: []any{p1, p2, p3}})
+	}
+
+	findAllByEmailOrderByIDDescLimit10Finder, err := base.Finder("FindAllByEmailOrderByIDDescLimit10", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindAllByEmailOrderByIDDescLimit10 = func(ctx context.Context, p1 string) ([]UserModel, error) {
+		return findAllByEmailOrderByIDDescLimit10Finder.AllArgs(ctx, 0, repo.Args{Values: []any{p1}})
+	}
+
+	findByTotalGreaterThanFinder, err := base.Finder("FindByTotalGreaterThan", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByTotalGreaterThan = func(ctx context.Context, p1 int) (*UserModel, error) {
+		return findByTotalGreaterThanFinder.OneArgs(ctx, repo.Args{Values: []any{p1}})
+	}
+
+	findByUserNameLikeFinder, err := base.Finder("FindByUserNameLike", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByUserNameLike = func(ctx context.Context, p1 string) (*UserModel, error) {
+		return findByUserNameLikeFinder.OneArgs(ctx, repo.Args{Values: []any{p1}})
+	}
+
+	findByTotalLessThanEqualAndStatusNotEqualFinder, err := base.Finder("FindByTotalLessThanEqualAndStatusNotEqual", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByTotalLessThanEqualAndStatusNotEqual = func(ctx context.Context, p1 int, p2 string) (*UserModel, error) {
+		return findByTotalLessThanEqualAndStatusNotEqualFinder.OneArgs(ctx, repo.Args{Values: []any{p1, p2}})
+	}
+
+	findAllByCreatedAtGreaterThanOrderByCreatedAtDescLimit5Finder, err := base.Finder("FindAllByCreatedAtGreaterThanOrderByCreatedAtDescLimit5", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindAllByCreatedAtGreaterThanOrderByCreatedAtDescLimit5 = func(ctx context.Context, p1 time.Time) ([]UserModel, error) {
+		return findAllByCreatedAtGreaterThanOrderByCreatedAtDescLimit5Finder.AllArgs(ctx, 0, repo.Args{Values: []any{p1}})
+	}
+
+	findByStatusInFinder, err := base.Finder("FindByStatusIn", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByStatusIn = func(ctx context.Context, p1 []string) (*UserModel, error) {
+		return findByStatusInFinder.OneArgs(ctx, repo.Args{Values: []any{p1}})
+	}
+
+	findByCreatedAtBetweenFinder, err := base.Finder("FindByCreatedAtBetween", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByCreatedAtBetween = func(ctx context.Context, p1 time.Time, p2 time.Time) (*UserModel, error) {
+		return findByCreatedAtBetweenFinder.OneArgs(ctx, repo.Args{Values: []any{p1, p2}})
+	}
+
+	findByCreatedAtIsNullFinder, err := base.Finder("FindByCreatedAtIsNull", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByCreatedAtIsNull = func(ctx context.Context) (*UserModel, error) {
+		return findByCreatedAtIsNullFinder.OneArgs(ctx, repo.Args{})
+	}
+
+	findByCreatedAtIsNotNullFinder, err := base.Finder("FindByCreatedAtIsNotNull", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindByCreatedAtIsNotNull = func(ctx context.Context) (*UserModel, error) {
+		return findByCreatedAtIsNotNullFinder.OneArgs(ctx, repo.Args{})
+	}
+
+	findFirstByStatusOrderByCreatedAtDescFinder, err := base.Finder("FindFirstByStatusOrderByCreatedAtDesc", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindFirstByStatusOrderByCreatedAtDesc = func(ctx context.Context, p1 string) (*UserModel, error) {
+		return findFirstByStatusOrderByCreatedAtDescFinder.OneArgs(ctx, repo.Args{Values: []any{p1}})
+	}
+
+	findTop3ByStatusFinder, err := base.Finder("FindTop3ByStatus", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindTop3ByStatus = func(ctx context.Context, p1 string) ([]UserModel, error) {
+		return findTop3ByStatusFinder.AllArgs(ctx, 0, repo.Args{Values: []any{p1}})
+	}
+
+	findDistinctByPartnerIdFinder, err := base.Finder("FindDistinctByPartnerId", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindDistinctByPartnerId = func(ctx context.Context, p1 string) ([]UserModel, error) {
+		return findDistinctByPartnerIdFinder.AllArgs(ctx, 0, repo.Args{Values: []any{p1}})
+	}
+
+	findAllByStatusFinder, err := base.Finder("FindAllByStatus", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindAllByStatus = func(ctx context.Context, p1 string, p2 repo.Limit) ([]UserModel, error) {
+		return findAllByStatusFinder.AllArgs(ctx, p2, repo.Args{Values: []any{p1}})
+	}
+
+	findAllByPartnerIdOrderByCreatedAtFinder, err := base.Finder("FindAllByPartnerIdOrderByCreatedAt", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindAllByPartnerIdOrderByCreatedAt = func(ctx context.Context, p1 string) iter.Seq2[UserModel, error] {
+		return findAllByPartnerIdOrderByCreatedAtFinder.StreamArgs(ctx, 0, repo.Args{Values: []any{p1}})
+	}
+
+	findAllByStatusAndPartnerIdFinder, err := base.Finder("FindAllByStatusAndPartnerId", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindAllByStatusAndPartnerId = func(ctx context.Context, p1 repo.Opt[string], p2 repo.Opt[string]) ([]UserModel, error) {
+		v1, ok1 := p1.Get()
+		v2, ok2 := p2.Get()
+		return findAllByStatusAndPartnerIdFinder.AllArgs(ctx, 0, repo.Args{Values: []any{v1, v2}, Present: []bool{ok1, ok2}})
+	}
+
+	sumTotalByPartnerIdFinder, err := base.Finder("SumTotalByPartnerId", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.SumTotalByPartnerId = func(ctx context.Context, p1 string) (int64, error) {
+		var out int64
+		err := sumTotalByPartnerIdFinder.AggregateArgs(ctx, &out, repo.Args{Values: []any{p1}})
+		return out, err
+	}
+
+	maxCreatedAtByStatusFinder, err := base.Finder("MaxCreatedAtByStatus", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.MaxCreatedAtByStatus = func(ctx context.Context, p1 string) (*time.Time, error) {
+		var out *time.Time
+		err := maxCreatedAtByStatusFinder.AggregateArgs(ctx, &out, repo.Args{Values: []any{p1}})
+		return out, err
+	}
+
+	countGroupByStatusFinder, err := base.Finder("CountGroupByStatus", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.CountGroupByStatus = func(ctx context.Context) (map[string]int64, error) {
+		var out map[string]int64
+		err := countGroupByStatusFinder.AggregateArgs(ctx, &out, repo.Args{})
+		return out, err
+	}
+
+	sumTotalGroupByStatusFinder, err := base.Finder("SumTotalGroupByStatus", `repo:"@Query"`)
+	if err != nil {
+		return nil, err
+	}
+	r.SumTotalGroupByStatus = func(ctx context.Context) ([]StatusTotal, error) {
+		var out []StatusTotal
+		err := sumTotalGroupByStatusFinder.AggregateArgs(ctx, &out, repo.Args{})
+		return out, err
+	}
+
+	r.FindActiveUsers = func(ctx context.Context, p1 time.Time) ([]UserModel, error) {
+		var out []UserModel
+		err := base.NamedQuery(ctx, "FindActiveUsers", &out, p1)
+		return out, err
+	}
+
+	r.CountUsersByPartner = func(ctx context.Context, p1 string) ([]PartnerTotal, error) {
+		var out []PartnerTotal
+		err := base.Call(ctx, `repo:"@Query(name=CountUsersByPartner)" readonly:"true"`, func(ctx context.Context) error {
+			return base.NamedQuery(ctx, "CountUsersByPartner", &out, p1)
+		})
+		return out, err
+	}
+
+	r.DeactivateUsers = func(ctx context.Context, p1 DeactivateParams) error {
+		return base.Call(ctx, `repo:"@Query(name=DeactivateUsers)" timeout:"2s"`, func(ctx context.Context) error {
+			_, err := base.NamedExec(ctx, "DeactivateUsers", p1)
+			return err
+		})
+	}
+
+	r.TransferTotal = func(ctx context.Context, p1 uuid.UUID, p2 uuid.UUID, p3 int) (TransferResult, error) {
+		var out TransferResult
+		err := base.CallProcedure(ctx, "transfer_total", &out, p1, p2, p3, repo.Out("status"), repo.Out("remaining"))
+		return out, err
+	}
+
+	r.UserBalance = func(ctx context.Context, p1 uuid.UUID) (int64, error) {
+		var out int64
+		err := base.CallFunction(ctx, "user_balance", &out, p1)
+		return out, err
+	}
+
+	findAllByPartnerIdAndStatusFinder, err := base.Finder("FindAllByPartnerIdAndStatus", `repo:"@Query" readonly:"true" timeout:"500ms" hint_mysql:"MAX_EXECUTION_TIME(500)" hint_postgres:"IndexScan(user_tbl)"`)
+	if err != nil {
+		return nil, err
+	}
+	r.FindAllByPartnerIdAndStatus = func(ctx context.Context, p1 string, p2 string) ([]UserModel, error) {
+		return findAllByPartnerIdAndStatusFinder.AllArgs(ctx, 0, repo.Args{Values: []any{p1, p2}})
+	}
+	return r, nil
+}
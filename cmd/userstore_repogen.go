@@ -0,0 +1,52 @@
+// Code generated by repogen; DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/xhkzeroone/go-database/repo"
+)
+
+// userStoreImpl cài đặt UserStore, các method dynamic gọi trực tiếp repo.Finder
+type userStoreImpl struct {
+	*repo.Repository[UserModel, uuid.UUID]
+	findByEmail                                     *repo.Finder[UserModel, uuid.UUID]
+	findAllByStatusAndPartnerIdOrderByCreatedAtDesc *repo.Finder[UserModel, uuid.UUID]
+	countByStatus                                   *repo.Finder[UserModel, uuid.UUID]
+}
+
+var _ UserStore = (*userStoreImpl)(nil)
+
+// NewUserStore tạo UserStore từ repository gốc, lỗi khi tên method không hợp lệ với entity
+func NewUserStore(base *repo.Repository[UserModel, uuid.UUID]) (UserStore, error) {
+	r := &userStoreImpl{Repository: base}
+	var err error
+	if r.findByEmail, err = base.Finder("FindByEmail", ``); err != nil {
+		return nil, err
+	}
+	if r.findAllByStatusAndPartnerIdOrderByCreatedAtDesc, err = base.Finder("FindAllByStatusAndPartnerIdOrderByCreatedAtDesc", `optional:"true"`); err != nil {
+		return nil, err
+	}
+	if r.countByStatus, err = base.Finder("CountByStatus", ``); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *userStoreImpl) FindByEmail(ctx context.Context, p1 string) (*UserModel, error) {
+	return r.findByEmail.OneArgs(ctx, repo.Args{Values: []any{p1}})
+}
+
+func (r *userStoreImpl) FindAllByStatusAndPartnerIdOrderByCreatedAtDesc(ctx context.Context, p1 *string, p2 *string) ([]UserModel, error) {
+	v1, ok1 := repo.OptOf(p1).Get()
+	v2, ok2 := repo.OptOf(p2).Get()
+	return r.findAllByStatusAndPartnerIdOrderByCreatedAtDesc.AllArgs(ctx, 0, repo.Args{Values: []any{v1, v2}, Present: []bool{ok1, ok2}})
+}
+
+func (r *userStoreImpl) CountByStatus(ctx context.Context, p1 string) (int64, error) {
+	var out int64
+	err := r.countByStatus.AggregateArgs(ctx, &out, repo.Args{Values: []any{p1}})
+	return out, err
+}
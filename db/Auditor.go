@@ -0,0 +1,43 @@
+package db
+
+import "context"
+
+type auditorKey struct{}
+
+// AuditorProvider cung cấp người thực hiện hiện tại (user id, username, ...) để ghi vào các field audit
+type AuditorProvider interface {
+	CurrentAuditor(ctx context.Context) (any, bool)
+}
+
+// AuditorProviderFunc cho phép dùng hàm làm AuditorProvider
+type AuditorProviderFunc func(ctx context.Context) (any, bool)
+
+func (f AuditorProviderFunc) CurrentAuditor(ctx context.Context) (any, bool) {
+	return f(ctx)
+}
+
+// WithAuditor gắn người thực hiện vào context (thường trong middleware xác thực)
+func WithAuditor(ctx context.Context, auditor any) context.Context {
+	return context.WithValue(ctx, auditorKey{}, auditor)
+}
+
+// AuditorFromContext lấy người thực hiện đã gắn bằng WithAuditor, là AuditorProvider mặc định
+func AuditorFromContext(ctx context.Context) (any, bool) {
+	auditor := ctx.Value(auditorKey{})
+	return auditor, auditor != nil
+}
+
+// WithAuditorProvider đăng ký AuditorProvider cho DataSource, mặc định dùng AuditorFromContext
+func WithAuditorProvider(provider AuditorProvider) Option {
+	return func(o *options) {
+		o.auditor = provider
+	}
+}
+
+// CurrentAuditor người thực hiện hiện tại theo AuditorProvider đã đăng ký
+func (p *DataSource) CurrentAuditor(ctx context.Context) (any, bool) {
+	if p.Auditor != nil {
+		return p.Auditor.CurrentAuditor(ctx)
+	}
+	return AuditorFromContext(ctx)
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -17,11 +18,19 @@ type options struct {
 	gormConfig *gorm.Config
 	debug      *bool
 	dsnBuilder DSNBuilder
+	auditor    AuditorProvider
+	replicas   []gorm.Dialector
 }
 
 // DataSource defines common database operations.
 type DataSource struct {
 	*gorm.DB
+	// Auditor cung cấp người thực hiện cho các field @CreatedBy/@LastModifiedBy, nil thì dùng AuditorFromContext
+	Auditor AuditorProvider
+	// Replicas các replica chỉ đọc, dùng trong ReadOnly
+	Replicas []*gorm.DB
+
+	replicaNext atomic.Uint64
 }
 
 // DSNBuilder defines how to build a gorm.Dialector based on config.
@@ -40,8 +49,9 @@ func (d *DefaultDSNBuilder) Build(c *Config) (gorm.Dialector, error) {
 		)
 		return postgres.Open(dsn), nil
 	case "mysql":
+		// clientFoundRows: RowsAffected là số dòng khớp WHERE (kể cả không đổi giá trị), repo dựa vào đó để báo ErrNotFound
 		dsn := fmt.Sprintf(
-			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&clientFoundRows=true",
 			c.User, c.Password, c.Host, c.Port, c.DBName,
 		)
 		return mysql.Open(dsn), nil
@@ -105,11 +115,43 @@ func Open(cfg *Config, opts ...Option) (*DataSource, error) {
 		gormCfg = opt.gormConfig
 	}
 
-	db, err := gorm.Open(dialector, gormCfg)
+	debugMode := cfg.Debug
+	if opt.debug != nil {
+		debugMode = *opt.debug
+	}
+
+	db, err := connect(cfg, dialector, gormCfg, debugMode)
 	if err != nil {
 		log.Printf("failed to connect database: %v", err)
 		return nil, err
 	}
+	if !clientFoundRows(dialector) {
+		log.Printf("mysql DSN thiếu clientFoundRows=true: Update/Patch/UpdateFields không đổi giá trị nào sẽ trả về ErrNotFound hoặc OptimisticLockError")
+	}
+	if debugMode {
+		log.Println("GORM debug mode is enabled")
+	}
+	ds := &DataSource{DB: db, Auditor: opt.auditor}
+	for _, d := range opt.replicas {
+		replica, err := connect(cfg, d, gormCfg, debugMode)
+		if err != nil {
+			log.Printf("failed to connect replica: %v", err)
+			_ = ds.Close()
+			return nil, err
+		}
+		ds.Replicas = append(ds.Replicas, replica)
+	}
+
+	log.Println("Successfully connected to database")
+	return ds, nil
+}
+
+// connect mở kết nối, kiểm tra bằng Ping và áp dụng cấu hình pool
+func connect(cfg *Config, dialector gorm.Dialector, gormCfg *gorm.Config, debug bool) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, gormCfg)
+	if err != nil {
+		return nil, err
+	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -130,24 +172,32 @@ func Open(cfg *Config, opts ...Option) (*DataSource, error) {
 		sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
 	}
 
-	debugMode := cfg.Debug
-	if opt.debug != nil {
-		debugMode = *opt.debug
-	}
-	if debugMode {
+	if debug {
 		db = db.Debug()
-		log.Println("GORM debug mode is enabled")
 	}
+	return db, nil
+}
 
-	log.Println("Successfully connected to database")
-	return &DataSource{DB: db}, nil
+// clientFoundRows false khi dialector là mysql mở bằng DSN không bật clientFoundRows: RowsAffected khi đó chỉ đếm
+// các dòng thực sự đổi giá trị, repo không phân biệt được UPDATE không đổi gì với không có dòng nào khớp
+func clientFoundRows(dialector gorm.Dialector) bool {
+	d, ok := dialector.(*mysql.Dialector)
+	if !ok || d.Config == nil || d.Conn != nil || d.DSNConfig == nil {
+		return true
+	}
+	return d.DSNConfig.ClientFoundRows
 }
 
-// Close đóng kết nối database
+// Close đóng kết nối database và các replica
 func (p *DataSource) Close() error {
 	if p == nil || p.DB == nil {
 		return nil
 	}
+	for _, replica := range p.Replicas {
+		if sqlDB, err := replica.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
 	sqlDB, err := p.DB.DB()
 	if err != nil {
 		return err
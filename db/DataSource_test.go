@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestClientFoundRows(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialector gorm.Dialector
+		want      bool
+	}{
+		{"mysql thiếu", mysql.Open("u:p@tcp(localhost:3306)/app?parseTime=True"), false},
+		{"mysql có", mysql.Open("u:p@tcp(localhost:3306)/app?parseTime=True&clientFoundRows=true"), true},
+		{"mysql.New", mysql.New(mysql.Config{DSN: "u:p@tcp(localhost:3306)/app"}), false},
+		{"mặc định", mustBuild(t, &Config{Driver: "mysql", Host: "localhost", Port: "3306"}), true},
+		{"postgres", postgres.Open("host=localhost"), true},
+	}
+	for _, tt := range tests {
+		if got := clientFoundRows(tt.dialector); got != tt.want {
+			t.Errorf("%s: clientFoundRows = %v, muốn %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func mustBuild(t *testing.T, cfg *Config) gorm.Dialector {
+	d, err := (&DefaultDSNBuilder{}).Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestReplicaWraparound(t *testing.T) {
+	p := &DataSource{Replicas: []*gorm.DB{{}, {}, {}}}
+	// Bộ đếm vượt quá MaxInt64 thì int(n-1) âm, chỉ số vẫn phải nằm trong khoảng
+	p.replicaNext.Store(1<<63 + 1)
+	for i := 0; i < 4; i++ {
+		if got, want := p.replica(), p.Replicas[(1<<63+1+uint64(i))%3]; got != want {
+			t.Errorf("lần %d: replica khác thứ tự vòng", i)
+		}
+	}
+}
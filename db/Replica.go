@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+type readOnlyKey struct{}
+
+// WithReplicas các replica chỉ đọc, mở cùng gorm config và cấu hình pool với DB chính.
+// Truy vấn gọi qua DataSource.ReadOnly (hoặc hàm dynamic có tag `readonly:"true"`) được chuyển sang replica.
+func WithReplicas(dialectors ...gorm.Dialector) Option {
+	return func(o *options) {
+		o.replicas = append(o.replicas, dialectors...)
+	}
+}
+
+// ReadOnly chạy fn chỉ đọc: dùng transaction trong ctx nếu có; có replica thì Conn trong fn trả về một replica
+// (luân phiên), không có replica thì fn chạy trong transaction READ ONLY của DB chính
+func (p *DataSource) ReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+	if len(p.Replicas) > 0 {
+		return fn(context.WithValue(ctx, readOnlyKey{}, true))
+	}
+	return p.Transactional(ctx, fn, &sql.TxOptions{ReadOnly: true})
+}
+
+// IsReadOnly ctx đang chạy trong DataSource.ReadOnly với replica
+func IsReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// replica chọn replica tiếp theo theo vòng
+func (p *DataSource) replica() *gorm.DB {
+	n := p.replicaNext.Add(1)
+	return p.Replicas[(n-1)%uint64(len(p.Replicas))]
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// WithTx gắn transaction vào context để các repository dùng chung
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext lấy transaction đang gắn trong context (nếu có)
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return tx, ok && tx != nil
+}
+
+// Conn trả về transaction trong ctx nếu có, replica khi chạy trong ReadOnly, ngược lại là DB gắn ctx
+func (p *DataSource) Conn(ctx context.Context) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	if len(p.Replicas) > 0 && IsReadOnly(ctx) {
+		return p.replica().WithContext(ctx)
+	}
+	return p.DB.WithContext(ctx)
+}
+
+// Transactional chạy fn trong transaction, mọi repository gọi với ctx truyền vào fn dùng chung transaction đó.
+// Commit khi fn trả nil, rollback khi fn trả lỗi hoặc panic. Nếu ctx đã có transaction thì dùng savepoint.
+func (p *DataSource) Transactional(ctx context.Context, fn func(ctx context.Context) error, opts ...*sql.TxOptions) error {
+	return p.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(WithTx(ctx, tx))
+	}, opts...)
+}
+
+// InTx kiểm tra truy vấn có đang chạy trong transaction hay không
+func InTx(q *gorm.DB) bool {
+	_, ok := q.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
@@ -0,0 +1,260 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// aggregatePattern tên hàm aggregate: Count|Sum|Avg|Min|Max[Field][By<điều kiện>][GroupBy<field>[And<field>...]]
+var aggregatePattern = regexp.MustCompile(`^(Count|Sum|Avg|Min|Max)([A-Z]|$)`)
+
+// aggregateQuery kết quả phân tích tên hàm aggregate
+type aggregateQuery struct {
+	function     string // COUNT, SUM, AVG, MIN, MAX
+	column       string // cột đã resolve, rỗng với COUNT(*)
+	alias        string // tên cột kết quả: count, sum_total, max_created_at
+	conditions   [][]whereCondition
+	groupBy      []string // cột GROUP BY đã resolve
+	groupAliases []string // tên cột kết quả của các cột group: status, partner_id
+}
+
+// MethodKind loại hàm dynamic theo tiền tố tên hàm (chưa kiểm tra phần còn lại của tên hàm): PlanFind với Find...By...,
+// PlanAggregate với Count|Sum|Avg|Min|Max..., rỗng khi không phải hàm dynamic. Dùng chung cho Finder và repogen.
+func MethodKind(methodName string) string {
+	switch {
+	case aggregatePattern.MatchString(methodName):
+		return PlanAggregate
+	case strings.HasPrefix(methodName, "Find"):
+		return PlanFind
+	}
+	return ""
+}
+
+// parseAggregateMethod phân tích tên hàm aggregate, dùng chung cú pháp điều kiện với FindBy.
+// Ví dụ: SumTotalByPartnerId, MaxCreatedAtByStatus, CountGroupByStatus, AvgTotalByStatusGroupByPartnerId.
+func parseAggregateMethod(methodName string, resolve func(string) (string, error)) (*aggregateQuery, error) {
+	m := aggregatePattern.FindStringSubmatch(methodName)
+	if m == nil {
+		return nil, fmt.Errorf("method name %s phải có dạng Count|Sum|Avg|Min|Max[Field][By...][GroupBy...]", methodName)
+	}
+	aq := &aggregateQuery{function: strings.ToUpper(m[1])}
+	rest, groupPart := extractSuffixPart(methodName[len(m[1]):], "GroupBy")
+
+	// Tách field và điều kiện tại chữ "By" đầu tiên mà phần trước nó là một field hợp lệ
+	field, wherePart := rest, ""
+	for i := 0; i+2 < len(rest); i++ {
+		if !strings.HasPrefix(rest[i:], "By") || !unicode.IsUpper(rune(rest[i+2])) {
+			continue
+		}
+		if i == 0 {
+			field, wherePart = "", rest[2:]
+			break
+		}
+		if column, err := resolve(rest[:i]); err == nil {
+			field, wherePart, aq.column = rest[:i], rest[i+2:], column
+			break
+		}
+	}
+
+	switch {
+	case aq.function == "COUNT" && field != "":
+		return nil, fmt.Errorf("method name %s: Count không nhận field, dùng CountBy... hoặc CountGroupBy...", methodName)
+	case aq.function == "COUNT":
+		aq.alias = "count"
+	case field == "":
+		return nil, fmt.Errorf("method name %s: thiếu field cần tính %s", methodName, m[1])
+	default:
+		if aq.column == "" {
+			column, err := resolve(field)
+			if err != nil {
+				return nil, err
+			}
+			aq.column = column
+		}
+		aq.alias = strings.ToLower(m[1]) + "_" + toSnakeCase(strings.ReplaceAll(field, "_", ""))
+	}
+
+	if wherePart != "" {
+		groups, err := parseWhereConditions(wherePart, resolve)
+		if err != nil {
+			return nil, err
+		}
+		aq.conditions = groups
+	}
+
+	if groupPart != "" {
+		for _, name := range splitKeyword(groupPart, "And") {
+			column, err := resolve(name)
+			if err != nil {
+				return nil, err
+			}
+			aq.groupBy = append(aq.groupBy, column)
+			aq.groupAliases = append(aq.groupAliases, toSnakeCase(strings.ReplaceAll(name, "_", "")))
+		}
+	}
+	return aq, nil
+}
+
+// selectExpr danh sách cột SELECT: các cột group rồi tới giá trị aggregate
+func (aq *aggregateQuery) selectExpr() string {
+	columns := make([]string, 0, len(aq.groupBy)+1)
+	for i, column := range aq.groupBy {
+		columns = append(columns, column+" AS "+aq.groupAliases[i])
+	}
+	target := "*"
+	if aq.column != "" {
+		target = aq.column
+	}
+	return strings.Join(append(columns, fmt.Sprintf("%s(%s) AS %s", aq.function, target, aq.alias)), ", ")
+}
+
+// build dựng truy vấn aggregate trên q (đã gắn model và phạm vi xóa mềm), present như bindConditions
+func (aq *aggregateQuery) build(q *gorm.DB, joins []string, params []any, present []bool) *gorm.DB {
+	for _, join := range joins {
+		q = q.Joins(join)
+	}
+	if where, args := bindConditions(aq.conditions, params, present); len(where) > 0 {
+		q = q.Where(strings.Join(where, " OR "), args...)
+	}
+	q = q.Select(aq.selectExpr())
+	if len(aq.groupBy) > 0 {
+		q = q.Group(strings.Join(aq.groupBy, ", "))
+	}
+	return q
+}
+
+// checkResult kiểm tra kiểu kết quả của hàm aggregate:
+//   - không có GroupBy: giá trị đơn (int64, float64, time.Time, *float64, ...), NULL trả về zero value/nil
+//   - GroupBy một field: map[K]V, K là giá trị group, V là giá trị aggregate
+//   - GroupBy: []S hoặc []*S, S là struct có field theo tên cột kết quả (Status, PartnerId, Count, SumTotal, ...)
+func (aq *aggregateQuery) checkResult(out reflect.Type) error {
+	switch {
+	case len(aq.groupBy) == 0:
+		if out.Kind() == reflect.Map || (out.Kind() == reflect.Slice && out.Elem().Kind() != reflect.Uint8) {
+			return fmt.Errorf("hàm aggregate không có GroupBy phải trả về giá trị đơn")
+		}
+	case out.Kind() == reflect.Map:
+		if len(aq.groupBy) != 1 {
+			return fmt.Errorf("trả về map chỉ dùng được khi GroupBy một field")
+		}
+	case out.Kind() != reflect.Slice || indirectType(out.Elem()).Kind() != reflect.Struct:
+		return fmt.Errorf("hàm aggregate có GroupBy phải trả về map[K]V hoặc slice struct")
+	}
+	return nil
+}
+
+// scan chạy truy vấn aggregate q và ghi kết quả vào dest (pointer tới kiểu đã qua checkResult)
+func (aq *aggregateQuery) scan(q *gorm.DB, dest reflect.Value) error {
+	out := dest.Type().Elem()
+	switch {
+	case len(aq.groupBy) == 0:
+		value := nullableScan(out)
+		if err := scanRow(q, value.Interface()); err != nil {
+			return err
+		}
+		dest.Elem().Set(nullableValue(value, out))
+		return nil
+	case out.Kind() == reflect.Slice:
+		return q.Scan(dest.Interface()).Error
+	}
+
+	rows, err := q.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	res := reflect.MakeMap(out)
+	for rows.Next() {
+		key, value := nullableScan(out.Key()), nullableScan(out.Elem())
+		if err := rows.Scan(key.Interface(), value.Interface()); err != nil {
+			return err
+		}
+		res.SetMapIndex(nullableValue(key, out.Key()), nullableValue(value, out.Elem()))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	dest.Elem().Set(res)
+	return nil
+}
+
+// makeAggregate tạo func cho hàm aggregate, kiểu kết quả xem checkResult
+func (r *Repository[T, ID]) makeAggregate(finder *Finder[T, ID], funcType reflect.Type, optionalTag string) (reflect.Value, error) {
+	aq := finder.aggregate
+	if funcType.NumOut() != 2 || funcType.Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
+		return reflect.Value{}, fmt.Errorf("hàm aggregate phải trả về (result, error)")
+	}
+	if n := countParams(aq.conditions); funcType.NumIn()-1 != n {
+		return reflect.Value{}, fmt.Errorf("số tham số (%d) không khớp với số lượng điều kiện (%d)", funcType.NumIn()-1, n)
+	}
+	optional, hasOptional, err := parseOptionalTag(optionalTag, funcType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	out := funcType.Out(0)
+	if err := aq.checkResult(out); err != nil {
+		return reflect.Value{}, err
+	}
+	binders := make([]argBinder, funcType.NumIn())
+	for j := 1; j < funcType.NumIn(); j++ {
+		binders[j] = paramBinder(funcType.In(j), optional)
+	}
+	zero, nilErr := reflect.Zero(out), reflect.Zero(funcType.Out(1))
+
+	return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+		ctx := args[0].Interface().(context.Context)
+		params := make([]any, 0, len(args)-1)
+		var present []bool
+		if hasOptional {
+			present = make([]bool, 0, len(args)-1)
+		}
+		for j := 1; j < len(args); j++ {
+			value, ok := binders[j](args[j])
+			params = append(params, value)
+			if hasOptional {
+				present = append(present, ok)
+			}
+		}
+		res := reflect.New(out)
+		err := r.call(ctx, finder.calls, func(ctx context.Context) error {
+			return aq.scan(aq.build(finder.hints.apply(r.reader(ctx)), finder.joins, params, present), res)
+		})
+		if err != nil {
+			return []reflect.Value{zero, reflect.ValueOf(err)}
+		}
+		return []reflect.Value{res.Elem(), nilErr}
+	}), nil
+}
+
+// nullableScan đích Scan chấp nhận NULL cho kiểu t (**V khi t là V, **V khi t là *V)
+func nullableScan(t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Ptr {
+		return reflect.New(t)
+	}
+	return reflect.New(reflect.PointerTo(t))
+}
+
+// nullableValue giá trị kiểu t đọc được từ nullableScan, NULL thành zero value (hoặc nil với pointer)
+func nullableValue(dest reflect.Value, t reflect.Type) reflect.Value {
+	v := dest.Elem()
+	if t.Kind() == reflect.Ptr {
+		return v
+	}
+	if v.IsNil() {
+		return reflect.Zero(t)
+	}
+	return v.Elem()
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// aggregateColumns các field của model giả định trong test, CreatedBy và Buyer chứa chữ "By"
+var aggregateColumns = map[string]string{
+	"Total":     "total",
+	"Status":    "status",
+	"PartnerId": "partner_id",
+	"CreatedAt": "created_at",
+	"CreatedBy": "created_by",
+	"Buyer":     "buyer",
+	"ByteSize":  "byte_size",
+}
+
+func resolveAggregateColumn(field string) (string, error) {
+	if column, ok := aggregateColumns[field]; ok {
+		return column, nil
+	}
+	return "", fmt.Errorf("không có field %s", field)
+}
+
+func TestParseAggregateMethod(t *testing.T) {
+	tests := []struct {
+		method  string
+		sel     string
+		where   string
+		groupBy string
+		err     string
+	}{
+		{method: "Count", sel: "COUNT(*) AS count"},
+		{method: "CountByStatus", sel: "COUNT(*) AS count", where: "(status = ?)"},
+		{method: "CountGroupByStatus", sel: "status AS status, COUNT(*) AS count", groupBy: "status"},
+		{method: "SumTotal", sel: "SUM(total) AS sum_total"},
+		{method: "SumTotalByPartnerId", sel: "SUM(total) AS sum_total", where: "(partner_id = ?)"},
+		{method: "SumTotalByBuyer", sel: "SUM(total) AS sum_total", where: "(buyer = ?)"},
+		{method: "AvgTotalByStatusGroupByPartnerId", sel: "partner_id AS partner_id, AVG(total) AS avg_total",
+			where: "(status = ?)", groupBy: "partner_id"},
+		{method: "MaxCreatedAtByStatusOrPartnerId", sel: "MAX(created_at) AS max_created_at", where: "(status = ?) OR (partner_id = ?)"},
+		// "By" nằm trong tên field: tách ở chữ By đầu tiên mà phần trước là field hợp lệ
+		{method: "MaxCreatedByByStatus", sel: "MAX(created_by) AS max_created_by", where: "(status = ?)"},
+		{method: "MinCreatedBy", sel: "MIN(created_by) AS min_created_by"},
+		{method: "CountByCreatedBy", sel: "COUNT(*) AS count", where: "(created_by = ?)"},
+		{method: "SumByteSizeByCreatedByGroupByCreatedByAndStatus",
+			sel:   "created_by AS created_by, status AS status, SUM(byte_size) AS sum_byte_size",
+			where: "(created_by = ?)", groupBy: "created_by, status"},
+		{method: "CountTotal", err: "Count không nhận field"},
+		{method: "SumByStatus", err: "thiếu field cần tính Sum"},
+		{method: "SumPrice", err: "không có field Price"},
+		{method: "SumTotalByPrice", err: "không có field Price"},
+		{method: "CountGroupByPrice", err: "không có field Price"},
+		{method: "Counter", err: "phải có dạng"},
+		{method: "FindByStatus", err: "phải có dạng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			aq, err := parseAggregateMethod(tt.method, resolveAggregateColumn)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			where, _ := bindConditions(aq.conditions, make([]any, countParams(aq.conditions)), nil)
+			if sel := aq.selectExpr(); sel != tt.sel {
+				t.Errorf("SELECT = %q, muốn %q", sel, tt.sel)
+			}
+			if got := strings.Join(where, " OR "); got != tt.where {
+				t.Errorf("WHERE = %q, muốn %q", got, tt.where)
+			}
+			if got := strings.Join(aq.groupBy, ", "); got != tt.groupBy {
+				t.Errorf("GROUP BY = %q, muốn %q", got, tt.groupBy)
+			}
+		})
+	}
+}
+
+type aggregateRow struct {
+	Status string
+	Count  int64
+}
+
+func TestAggregateCheckResult(t *testing.T) {
+	scalar := &aggregateQuery{function: "SUM", column: "total"}
+	group := &aggregateQuery{function: "COUNT", groupBy: []string{"status"}}
+	groups := &aggregateQuery{function: "COUNT", groupBy: []string{"status", "partner_id"}}
+	tests := []struct {
+		name string
+		aq   *aggregateQuery
+		out  any
+		err  string
+	}{
+		{name: "int64", aq: scalar, out: int64(0)},
+		{name: "float64", aq: scalar, out: float64(0)},
+		{name: "*float64", aq: scalar, out: (*float64)(nil)},
+		{name: "time.Time", aq: scalar, out: time.Time{}},
+		{name: "[]byte", aq: scalar, out: []byte(nil)},
+		{name: "map không GroupBy", aq: scalar, out: map[string]int64(nil), err: "phải trả về giá trị đơn"},
+		{name: "slice không GroupBy", aq: scalar, out: []aggregateRow(nil), err: "phải trả về giá trị đơn"},
+		{name: "map GroupBy một field", aq: group, out: map[string]int64(nil)},
+		{name: "map giá trị pointer", aq: group, out: map[string]*float64(nil)},
+		{name: "slice struct", aq: group, out: []aggregateRow(nil)},
+		{name: "slice pointer struct", aq: groups, out: []*aggregateRow(nil)},
+		{name: "map GroupBy hai field", aq: groups, out: map[string]int64(nil), err: "chỉ dùng được khi GroupBy một field"},
+		{name: "giá trị đơn có GroupBy", aq: group, out: int64(0), err: "phải trả về map[K]V hoặc slice struct"},
+		{name: "slice không phải struct", aq: group, out: []int64(nil), err: "phải trả về map[K]V hoặc slice struct"},
+	}
+	for _, tt := range tests {
+		err := tt.aq.checkResult(reflect.TypeOf(tt.out))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: lỗi = %v, muốn chứa %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestMethodKind(t *testing.T) {
+	tests := map[string]string{
+		"Count":                PlanAggregate,
+		"CountByStatus":        PlanAggregate,
+		"SumTotalGroupByState": PlanAggregate,
+		"MaxCreatedAt":         PlanAggregate,
+		"Counter":              "",
+		"Summary":              "",
+		"FindByStatus":         PlanFind,
+		"FindAllByStatus":      PlanFind,
+		"FindTop3ByStatus":     PlanFind,
+		"GetByStatus":          "",
+		"":                     "",
+	}
+	for name, want := range tests {
+		if got := MethodKind(name); got != want {
+			t.Errorf("MethodKind(%q) = %q, muốn %q", name, got, want)
+		}
+	}
+}
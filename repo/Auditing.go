@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// audit các field audit của entity, đánh dấu bằng tag repo:"@CreatedDate", "@LastModifiedDate", "@CreatedBy", "@LastModifiedBy"
+type audit struct {
+	createdDate      *schema.Field
+	lastModifiedDate *schema.Field
+	createdBy        *schema.Field
+	lastModifiedBy   *schema.Field
+}
+
+// parseAuditTag ghi nhận field audit theo tag, bỏ qua các tag khác
+func (a *audit) parseAuditTag(s *schema.Schema, field *schema.Field, tag string) error {
+	switch tag {
+	case "@CreatedDate", "@LastModifiedDate":
+		if field.IndirectFieldType != timeType && !isIntegerKind(field.IndirectFieldType.Kind()) {
+			return fmt.Errorf("repo: field %s %s.%s phải là time.Time hoặc số nguyên (unix)", tag, s.Name, field.Name)
+		}
+		if tag == "@CreatedDate" {
+			a.createdDate = field
+		} else {
+			a.lastModifiedDate = field
+		}
+	case "@CreatedBy":
+		a.createdBy = field
+	case "@LastModifiedBy":
+		a.lastModifiedBy = field
+	}
+	return nil
+}
+
+// auditing cho biết entity có field audit nào không
+func (a *audit) auditing() bool {
+	return a.createdDate != nil || a.lastModifiedDate != nil || a.createdBy != nil || a.lastModifiedBy != nil
+}
+
+// createdColumns các cột chỉ ghi khi tạo, không được ghi đè khi cập nhật/upsert
+func (a *audit) createdColumns() []string {
+	var columns []string
+	for _, field := range []*schema.Field{a.createdDate, a.createdBy} {
+		if field != nil {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns
+}
+
+// auditValues giá trị của các field audit cần ghi: khi tạo gồm cả field @Created*, khi cập nhật chỉ @LastModified*.
+// Người thực hiện lấy từ AuditorProvider của DataSource, không có thì bỏ qua field *By.
+func (r *Repository[T, ID]) auditValues(ctx context.Context, a *audit, create bool) map[*schema.Field]any {
+	if !a.auditing() {
+		return nil
+	}
+	values := make(map[*schema.Field]any, 4)
+	now := r.DB.NowFunc()
+	auditor, hasAuditor := r.CurrentAuditor(ctx)
+	if a.lastModifiedDate != nil {
+		values[a.lastModifiedDate] = now
+	}
+	if a.lastModifiedBy != nil && hasAuditor {
+		values[a.lastModifiedBy] = auditor
+	}
+	if create {
+		if a.createdDate != nil {
+			values[a.createdDate] = now
+		}
+		if a.createdBy != nil && hasAuditor {
+			values[a.createdBy] = auditor
+		}
+	}
+	return values
+}
+
+// applyAudit gán giá trị audit vào entity. Field @Created* chỉ được gán khi đang zero
+// để giữ nguyên giá trị khi nhập dữ liệu cũ; trả về map cột -> giá trị đã gán để dùng trong câu UPDATE.
+func applyAudit(ctx context.Context, rv reflect.Value, values map[*schema.Field]any, a *audit) map[string]any {
+	set := make(map[string]any, len(values))
+	for field, value := range values {
+		if field == a.createdDate || field == a.createdBy {
+			if _, isZero := field.ValueOf(ctx, rv); !isZero {
+				continue
+			}
+		}
+		if err := field.Set(ctx, rv, value); err != nil {
+			continue
+		}
+		set[field.DBName], _ = field.ValueOf(ctx, rv)
+	}
+	return set
+}
+
+// auditCreate gán các field audit cho entity/slice entity trước khi insert
+func (r *Repository[T, ID]) auditCreate(ctx context.Context, meta *entityMeta, value any) {
+	values := r.auditValues(ctx, &meta.audit, true)
+	if values == nil {
+		return
+	}
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			applyAudit(ctx, reflect.Indirect(rv.Index(i)), values, &meta.audit)
+		}
+		return
+	}
+	applyAudit(ctx, rv, values, &meta.audit)
+}
+
+// auditUpdate gán các field @LastModified* cho entity trước khi cập nhật, trả về map cột -> giá trị
+func (r *Repository[T, ID]) auditUpdate(ctx context.Context, meta *entityMeta, rv reflect.Value) map[string]any {
+	values := r.auditValues(ctx, &meta.audit, false)
+	if values == nil {
+		return nil
+	}
+	return applyAudit(ctx, rv, values, &meta.audit)
+}
+
+// auditColumns map cột -> giá trị @LastModified* cho câu UPDATE không có entity (UpdateFields)
+func (r *Repository[T, ID]) auditColumns(ctx context.Context, meta *entityMeta) map[string]any {
+	values := r.auditValues(ctx, &meta.audit, false)
+	set := make(map[string]any, len(values))
+	for field, value := range values {
+		if field.IndirectFieldType != timeType && isIntegerKind(field.IndirectFieldType.Kind()) {
+			if t, ok := value.(time.Time); ok {
+				value = t.Unix()
+			}
+		}
+		set[field.DBName] = value
+	}
+	return set
+}
@@ -0,0 +1,285 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultBatchSize số bản ghi mỗi câu INSERT khi InsertAll/UpsertAll không chỉ định batch size
+	defaultBatchSize = 100
+	// defaultIDChunkSize số ID tối đa trong một câu IN, đủ nhỏ so với giới hạn tham số của driver
+	// (postgres 65535, mysql prepared statement 65535, sqlite 32766)
+	defaultIDChunkSize = 1000
+)
+
+// IDChunkSize số ID tối đa trong mỗi câu IN của FindAllByIDs/DeleteAllByIDs
+func IDChunkSize(n int) Option {
+	return func(o *options) {
+		o.idChunkSize = n
+	}
+}
+
+// UpsertOption cấu hình câu upsert (ON CONFLICT trên postgres/sqlite, ON DUPLICATE KEY trên mysql)
+type UpsertOption func(o *upsertOptions)
+
+type upsertOptions struct {
+	conflict  []string
+	update    []string
+	doNothing bool
+}
+
+// OnConflict các field/cột của ràng buộc unique xảy ra xung đột, mặc định là khóa chính (mysql bỏ qua)
+func OnConflict(columns ...string) UpsertOption {
+	return func(o *upsertOptions) {
+		o.conflict = columns
+	}
+}
+
+// UpdateColumns chỉ cập nhật các field/cột này khi xung đột, mặc định cập nhật mọi cột trừ khóa chính
+func UpdateColumns(columns ...string) UpsertOption {
+	return func(o *upsertOptions) {
+		o.update = columns
+	}
+}
+
+// DoNothing bỏ qua bản ghi bị xung đột thay vì cập nhật
+func DoNothing() UpsertOption {
+	return func(o *upsertOptions) {
+		o.doNothing = true
+	}
+}
+
+// onConflictClause dựng clause.OnConflict, field name được đổi sang tên cột theo schema
+func (m *entityMeta) onConflictClause(opts []UpsertOption) (clause.OnConflict, error) {
+	o := &upsertOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	oc := clause.OnConflict{DoNothing: o.doNothing}
+	for _, name := range o.conflict {
+		column, err := m.column(name)
+		if err != nil {
+			return oc, err
+		}
+		oc.Columns = append(oc.Columns, clause.Column{Name: column})
+	}
+	if o.doNothing {
+		return oc, nil
+	}
+	if len(oc.Columns) == 0 {
+		for _, field := range m.schema.PrimaryFields {
+			oc.Columns = append(oc.Columns, clause.Column{Name: field.DBName})
+		}
+	}
+	if len(o.update) == 0 {
+		if created := m.audit.createdColumns(); len(created) > 0 {
+			oc.DoUpdates = clause.AssignmentColumns(m.updatableColumns(created))
+		} else {
+			oc.UpdateAll = true
+		}
+		return oc, nil
+	}
+	columns := make([]string, 0, len(o.update))
+	for _, name := range o.update {
+		column, err := m.column(name)
+		if err != nil {
+			return oc, err
+		}
+		columns = append(columns, column)
+	}
+	oc.DoUpdates = clause.AssignmentColumns(columns)
+	return oc, nil
+}
+
+// updatableColumns các cột được ghi đè khi upsert: trừ khóa chính, cột tự gán lúc tạo và các cột exclude
+func (m *entityMeta) updatableColumns(exclude []string) []string {
+	var columns []string
+	for _, field := range m.schema.Fields {
+		if field.DBName == "" || field.PrimaryKey || !field.Creatable || !field.Updatable ||
+			field.AutoCreateTime > 0 || slices.Contains(exclude, field.DBName) {
+			continue
+		}
+		columns = append(columns, field.DBName)
+	}
+	return columns
+}
+
+// column đổi tên field hoặc tên cột sang tên cột
+func (m *entityMeta) column(name string) (string, error) {
+	field := m.schema.LookUpField(name)
+	if field == nil || field.DBName == "" {
+		return "", fmt.Errorf("repo: không tìm thấy field %s trong %s", name, m.schema.Name)
+	}
+	return field.DBName, nil
+}
+
+// InsertAll thêm nhiều entity, mỗi câu INSERT tối đa batchSize bản ghi (<= 0 dùng mặc định 100).
+// Khóa chính sinh bởi DB được gán lại vào các phần tử của entities.
+func (r *Repository[T, ID]) InsertAll(ctx context.Context, entities []T, batchSize int) error {
+	return r.withGeneratedIDs(ctx, entities, func() error {
+		if r.opts.history {
+			return r.recordHistory(ctx, r.entityIDs(ctx, entities), func(ctx context.Context, r *Repository[T, ID]) error {
+				return r.InsertAll(ctx, entities, batchSize)
+			})
+		}
+		if len(entities) == 0 {
+			return nil
+		}
+		meta, err := r.entity()
+		if err != nil {
+			return err
+		}
+		if batchSize <= 0 {
+			batchSize = defaultBatchSize
+		}
+		if err := r.prepareCreate(ctx, meta, entities); err != nil {
+			return err
+		}
+		if err := r.session(ctx).CreateInBatches(entities, batchSize).Error; err != nil {
+			return err
+		}
+		r.track(ctx, entities)
+		return nil
+	})
+}
+
+// Upsert thêm entity, nếu xung đột ràng buộc unique thì cập nhật theo opts
+func (r *Repository[T, ID]) Upsert(ctx context.Context, entity *T, opts ...UpsertOption) error {
+	return r.withGeneratedIDs(ctx, entity, func() error {
+		if r.opts.history {
+			return r.recordHistory(ctx, r.entityIDs(ctx, entity), func(ctx context.Context, r *Repository[T, ID]) error {
+				return r.Upsert(ctx, entity, opts...)
+			})
+		}
+		meta, err := r.entity()
+		if err != nil {
+			return err
+		}
+		oc, err := meta.onConflictClause(opts)
+		if err != nil {
+			return err
+		}
+		if err := r.prepareCreate(ctx, meta, entity); err != nil {
+			return err
+		}
+		return r.session(ctx).Clauses(oc).Create(entity).Error
+	})
+}
+
+// UpsertAll upsert nhiều entity theo batch (batchSize <= 0 dùng mặc định 100)
+func (r *Repository[T, ID]) UpsertAll(ctx context.Context, entities []T, batchSize int, opts ...UpsertOption) error {
+	return r.withGeneratedIDs(ctx, entities, func() error {
+		if r.opts.history {
+			return r.recordHistory(ctx, r.entityIDs(ctx, entities), func(ctx context.Context, r *Repository[T, ID]) error {
+				return r.UpsertAll(ctx, entities, batchSize, opts...)
+			})
+		}
+		if len(entities) == 0 {
+			return nil
+		}
+		meta, err := r.entity()
+		if err != nil {
+			return err
+		}
+		oc, err := meta.onConflictClause(opts)
+		if err != nil {
+			return err
+		}
+		if batchSize <= 0 {
+			batchSize = defaultBatchSize
+		}
+		if err := r.prepareCreate(ctx, meta, entities); err != nil {
+			return err
+		}
+		return r.session(ctx).Clauses(oc).CreateInBatches(entities, batchSize).Error
+	})
+}
+
+// FindAllByIDs tìm các entity theo danh sách ID, chia nhỏ câu IN theo IDChunkSize
+func (r *Repository[T, ID]) FindAllByIDs(ctx context.Context, ids []ID) ([]T, error) {
+	meta, err := r.entity()
+	if err != nil {
+		return nil, err
+	}
+	var list []T
+	for _, chunk := range chunkIDs(ids, r.idChunkSize()) {
+		cond, err := idIn(meta, chunk)
+		if err != nil {
+			return nil, err
+		}
+		var part []T
+		if err := r.query(ctx).Where(cond).Find(&part).Error; err != nil {
+			return nil, err
+		}
+		list = append(list, part...)
+	}
+	r.track(ctx, list)
+	return list, nil
+}
+
+// DeleteAllByIDs xóa các entity theo danh sách ID, trả về số dòng bị xóa.
+// Khi phải chia nhiều câu DELETE, tất cả chạy trong cùng một transaction.
+func (r *Repository[T, ID]) DeleteAllByIDs(ctx context.Context, ids []ID) (int64, error) {
+	if r.opts.history {
+		var n int64
+		err := r.recordHistory(ctx, idList(ids...), func(ctx context.Context, r *Repository[T, ID]) (err error) {
+			n, err = r.DeleteAllByIDs(ctx, ids)
+			return err
+		})
+		return n, err
+	}
+	meta, err := r.entity()
+	if err != nil {
+		return 0, err
+	}
+	chunks := chunkIDs(ids, r.idChunkSize())
+	var total int64
+	deleteChunks := func(ctx context.Context) error {
+		for _, chunk := range chunks {
+			cond, err := idIn(meta, chunk)
+			if err != nil {
+				return err
+			}
+			res := r.remove(r.session(ctx).Where(cond), meta, new(T))
+			if res.Error != nil {
+				return res.Error
+			}
+			total += res.RowsAffected
+		}
+		return nil
+	}
+	if len(chunks) > 1 {
+		err = r.Transactional(ctx, deleteChunks)
+	} else {
+		err = deleteChunks(ctx)
+	}
+	r.untrackIDs(ctx, meta, ids...)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *Repository[T, ID]) idChunkSize() int {
+	if r.opts.idChunkSize > 0 {
+		return r.opts.idChunkSize
+	}
+	return defaultIDChunkSize
+}
+
+// chunkIDs chia danh sách ID thành các phần tối đa size phần tử
+func chunkIDs[ID any](ids []ID, size int) [][]ID {
+	var chunks [][]ID
+	for len(ids) > size {
+		chunks = append(chunks, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
@@ -3,7 +3,9 @@ package repo
 import (
 	"context"
 	"fmt"
+	"iter"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
@@ -16,6 +18,55 @@ type QueryParts struct {
 	WhereClauses []string
 	OrderBy      string
 	Limit        int
+	Distinct     bool
+	Joins        []string // JOIN sinh ra khi điều kiện đi qua quan hệ
+	Select       string   // cột select khi có JOIN (ví dụ: "user_tbl".*)
+
+	conditions [][]whereCondition // các điều kiện của WhereClauses, dùng để bỏ điều kiện có tham số tùy chọn vắng mặt
+	where      string             // WhereClauses đã nối bằng OR, ghép sẵn một lần khi phân tích tên hàm
+}
+
+// Limit là tham số giới hạn số bản ghi truyền lúc runtime cho hàm dynamic,
+// ví dụ: FindAllByStatus func(ctx context.Context, status string, limit repo.Limit) ([]UserModel, error).
+// Tham số này không tham gia vào điều kiện WHERE và ghi đè giới hạn khai báo trong tên hàm (TopN, LimitN).
+type Limit int
+
+var limitType = reflect.TypeOf(Limit(0))
+
+// methodSubject phần đầu tên hàm trước "By" (Find, FindAll, FindDistinct, FindFirst, FindTop10, ...)
+type methodSubject struct {
+	All      bool
+	Distinct bool
+	Limited  bool // có First hoặc TopN
+	Limit    int
+}
+
+var subjectPattern = regexp.MustCompile(`^Find(All)?(Distinct)?(First|Top)?(\d*)By`)
+
+// parseSubject tách phần chủ ngữ của tên hàm, trả về phần còn lại sau "By"
+func parseSubject(methodName string) (*methodSubject, string, error) {
+	m := subjectPattern.FindStringSubmatch(methodName)
+	if m == nil {
+		return nil, "", fmt.Errorf("method name %s phải có dạng Find[All][Distinct][First|TopN]By...", methodName)
+	}
+	subject := &methodSubject{
+		All:      m[1] != "",
+		Distinct: m[2] != "",
+		Limited:  m[3] != "",
+	}
+	if m[4] != "" {
+		if !subject.Limited {
+			return nil, "", fmt.Errorf("method name %s: số lượng chỉ đi sau First hoặc Top", methodName)
+		}
+		n, err := strconv.Atoi(m[4])
+		if err != nil || n <= 0 {
+			return nil, "", fmt.Errorf("method name %s: số lượng bản ghi không hợp lệ %q", methodName, m[4])
+		}
+		subject.Limit = n
+	} else if subject.Limited {
+		subject.Limit = 1
+	}
+	return subject, methodName[len(m[0]):], nil
 }
 
 // toSnakeCase chuẩn hơn (ví dụ: UserName -> user_name, URLString -> url_string)
@@ -55,73 +106,143 @@ func extractSuffixPart(s, key string) (before, part string) {
 	return s, ""
 }
 
-// parseWhereConditions tách các điều kiện WHERE (AND/OR, toán tử)
-func parseWhereConditions(methodName string, parseFieldOp func(string) (string, string)) ([]string, []int, error) {
-	orConditions := strings.Split(methodName, "Or")
-	whereClauses := make([]string, 0, len(orConditions))
-	paramCounts := make([]int, 0, len(orConditions))
+// splitKeyword tách s theo từ khóa (And/Or) chỉ khi từ khóa đứng trước một chữ hoa,
+// để không cắt nhầm tên field như Orders, Origin, Android
+func splitKeyword(s, keyword string) []string {
+	var parts []string
+	start := 0
+	for i := 1; i+len(keyword) < len(s); i++ {
+		if strings.HasPrefix(s[i:], keyword) && unicode.IsUpper(rune(s[i+len(keyword)])) {
+			parts = append(parts, s[start:i])
+			start = i + len(keyword)
+			i = start
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// snakeCaseColumn resolver mặc định: tên field -> snake_case
+func snakeCaseColumn(field string) (string, error) {
+	return toSnakeCase(field), nil
+}
+
+// whereCondition một điều kiện trong tên hàm (ví dụ: "users"."total" > ?), params là số tham số nó nhận
+type whereCondition struct {
+	sql    string
+	params int
+	column string // cột đã resolve
+	op     string // toán tử SQL (=, >, IN, LIKE, IS NULL, ...)
+}
+
+// parseWhereConditions tách các điều kiện WHERE thành các nhóm AND, các nhóm nối với nhau bằng OR
+func parseWhereConditions(methodName string, resolve func(string) (string, error)) ([][]whereCondition, error) {
+	orConditions := splitKeyword(methodName, "Or")
+	groups := make([][]whereCondition, 0, len(orConditions))
 
 	for _, orCond := range orConditions {
-		andConditions := strings.Split(orCond, "And")
-		andClauses := make([]string, 0, len(andConditions))
-		countParams := 0
+		andConditions := splitKeyword(orCond, "And")
+		group := make([]whereCondition, 0, len(andConditions))
 		for _, andCond := range andConditions {
 			field, op := parseFieldOp(andCond)
+			column, err := resolve(field)
+			if err != nil {
+				return nil, err
+			}
 			switch op {
 			case "IN":
-				andClauses = append(andClauses, fmt.Sprintf("%s IN (?)", toSnakeCase(field)))
-				countParams++ // IN nhận 1 tham số là slice
+				group = append(group, whereCondition{fmt.Sprintf("%s IN (?)", column), 1, column, op}) // IN nhận 1 tham số là slice
 			case "BETWEEN":
-				andClauses = append(andClauses, fmt.Sprintf("%s BETWEEN ? AND ?", toSnakeCase(field)))
-				countParams += 2 // BETWEEN nhận 2 tham số
+				group = append(group, whereCondition{fmt.Sprintf("%s BETWEEN ? AND ?", column), 2, column, op}) // BETWEEN nhận 2 tham số
 			case "IS NULL", "IS NOT NULL":
-				andClauses = append(andClauses, fmt.Sprintf("%s %s", toSnakeCase(field), op))
-				// IS NULL không nhận tham số
+				group = append(group, whereCondition{fmt.Sprintf("%s %s", column, op), 0, column, op}) // IS NULL không nhận tham số
 			default:
-				andClauses = append(andClauses, fmt.Sprintf("%s %s ?", toSnakeCase(field), op))
-				countParams++
+				group = append(group, whereCondition{fmt.Sprintf("%s %s ?", column, op), 1, column, op})
 			}
 		}
-		group := "(" + strings.Join(andClauses, " AND ") + ")"
-		whereClauses = append(whereClauses, group)
-		paramCounts = append(paramCounts, countParams)
+		groups = append(groups, group)
 	}
-	return whereClauses, paramCounts, nil
+	return groups, nil
 }
 
-func parseMethodName(rawMethodName string) (*QueryParts, error) {
-	const prefix = "FindBy"
-	if !strings.HasPrefix(rawMethodName, prefix) {
-		return nil, fmt.Errorf("method name must start with %s", prefix)
+// bindConditions ghép các nhóm điều kiện thành WHERE clause, present (nil: mọi tham số có mặt) cho biết tham số
+// tùy chọn nào có giá trị: điều kiện có tham số vắng mặt bị bỏ, nhóm không còn điều kiện nào cũng bị bỏ
+func bindConditions(groups [][]whereCondition, params []any, present []bool) ([]string, []any) {
+	clauses := make([]string, 0, len(groups))
+	args := params
+	if present != nil {
+		args = make([]any, 0, len(params))
 	}
-	methodName := rawMethodName[len(prefix):]
-
-	qp := &QueryParts{}
-
-	// Map các hậu tố sang toán tử SQL
-	operatorMap := []struct {
-		Suffix string
-		SQLOp  string
-	}{
-		{"GreaterThanEqual", ">="},
-		{"LessThanEqual", "<="},
-		{"GreaterThan", ">"},
-		{"LessThan", "<"},
-		{"NotEqual", "!="},
-		{"Like", "LIKE"},
-		{"In", "IN"},
-		{"Between", "BETWEEN"},
-		{"IsNull", "IS NULL"},
-		{"IsNotNull", "IS NOT NULL"},
+	next := 0
+	for _, group := range groups {
+		parts := make([]string, 0, len(group))
+		for _, cond := range group {
+			keep := true
+			for i := next; i < next+cond.params && present != nil; i++ {
+				keep = keep && present[i]
+			}
+			if keep {
+				parts = append(parts, cond.sql)
+				if present != nil {
+					args = append(args, params[next:next+cond.params]...)
+				}
+			}
+			next += cond.params
+		}
+		if len(parts) > 0 {
+			clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+		}
 	}
+	return clauses, args
+}
 
-	parseFieldOp := func(part string) (field, op string) {
-		for _, m := range operatorMap {
-			if strings.HasSuffix(part, m.Suffix) {
-				return part[:len(part)-len(m.Suffix)], m.SQLOp
-			}
+// countParams tổng số tham số của các điều kiện
+func countParams(groups [][]whereCondition) int {
+	n := 0
+	for _, group := range groups {
+		for _, cond := range group {
+			n += cond.params
+		}
+	}
+	return n
+}
+
+// operatorMap các hậu tố toán tử trong tên hàm và toán tử SQL tương ứng (hậu tố dài xét trước)
+var operatorMap = []struct {
+	Suffix string
+	SQLOp  string
+}{
+	{"GreaterThanEqual", ">="},
+	{"LessThanEqual", "<="},
+	{"GreaterThan", ">"},
+	{"LessThan", "<"},
+	{"NotEqual", "!="},
+	{"Like", "LIKE"},
+	{"In", "IN"},
+	{"Between", "BETWEEN"},
+	{"IsNull", "IS NULL"},
+	{"IsNotNull", "IS NOT NULL"},
+}
+
+// parseFieldOp tách một điều kiện thành tên field và toán tử SQL, mặc định là "="
+func parseFieldOp(part string) (field, op string) {
+	for _, m := range operatorMap {
+		if strings.HasSuffix(part, m.Suffix) {
+			return part[:len(part)-len(m.Suffix)], m.SQLOp
 		}
-		return part, "="
+	}
+	return part, "="
+}
+
+// parseMethodName phân tích tên hàm thành QueryParts, resolve dùng để ánh xạ tên field sang cột
+func parseMethodName(rawMethodName string, resolve func(string) (string, error)) (*QueryParts, error) {
+	subject, methodName, err := parseSubject(rawMethodName)
+	if err != nil {
+		return nil, err
+	}
+
+	qp := &QueryParts{
+		Distinct: subject.Distinct,
+		Limit:    subject.Limit,
 	}
 
 	// Tách các phần hậu tố
@@ -131,7 +252,11 @@ func parseMethodName(rawMethodName string) (*QueryParts, error) {
 	// Parse OrderBy
 	if orderByPart != "" {
 		field, dir := parseOrderBy(orderByPart)
-		qp.OrderBy = fmt.Sprintf("%s %s", toSnakeCase(field), dir)
+		column, err := resolve(field)
+		if err != nil {
+			return nil, err
+		}
+		qp.OrderBy = fmt.Sprintf("%s %s", column, dir)
 	}
 
 	// Parse Limit
@@ -140,41 +265,73 @@ func parseMethodName(rawMethodName string) (*QueryParts, error) {
 		if err != nil {
 			return nil, fmt.Errorf("invalid limit number: %v", err)
 		}
+		if subject.Limited {
+			return nil, fmt.Errorf("method name %s không được dùng đồng thời First/TopN và LimitN", rawMethodName)
+		}
 		qp.Limit = n
 	}
 
-	// Parse WHERE
-	whereClauses, _, err := parseWhereConditions(methodName, parseFieldOp)
-	if err != nil {
-		return nil, err
+	// Parse WHERE (cho phép bỏ trống, ví dụ: FindFirstByOrderByCreatedAtDesc)
+	if methodName != "" {
+		groups, err := parseWhereConditions(methodName, resolve)
+		if err != nil {
+			return nil, err
+		}
+		qp.conditions = groups
+		qp.WhereClauses, _ = bindConditions(groups, nil, nil)
+		qp.where = strings.Join(qp.WhereClauses, " OR ")
 	}
-	qp.WhereClauses = whereClauses
 
 	return qp, nil
 }
 
-func buildGormQuery(db *gorm.DB, qp *QueryParts, args []interface{}) *gorm.DB {
-	whereClause := strings.Join(qp.WhereClauses, " OR ")
-	q := db.Where(whereClause, args...)
+func buildGormQuery(db *gorm.DB, qp *QueryParts, args []interface{}, limit int) *gorm.DB {
+	q := db
+	for _, join := range qp.Joins {
+		q = q.Joins(join)
+	}
+	if qp.where != "" {
+		q = q.Where(qp.where, args...)
+	} else if len(qp.WhereClauses) > 0 {
+		q = q.Where(strings.Join(qp.WhereClauses, " OR "), args...)
+	}
+	selectExpr := "*"
+	if qp.Select != "" {
+		selectExpr = qp.Select
+	}
+	if qp.Distinct {
+		q = q.Distinct(selectExpr)
+	} else if qp.Select != "" {
+		q = q.Select(selectExpr)
+	}
 	if qp.OrderBy != "" {
 		q = q.Order(qp.OrderBy)
 	}
-	if qp.Limit > 0 {
-		q = q.Limit(qp.Limit)
+	if limit > 0 {
+		q = q.Limit(limit)
 	}
 	return q
 }
 
-// FillFuncFields inject các func dynamic vào struct repo có tag `repo:"@Query"`
+// FillFuncFields inject các func dynamic vào struct repo có tag `repo:"@Query"`.
+// Tag `preload:"Orders,Partner"` nạp sẵn quan hệ, kèm `fetch:"join"` để nạp has-one/belongs-to bằng JOIN.
+// Tag `lock:"update,skip_locked"` đọc với SELECT ... FOR UPDATE SKIP LOCKED (bắt buộc trong transaction).
+// Tag `timeout:"500ms"`, `readonly:"true"` và `hint_<dialect>:"..."` áp dụng cho từng hàm (xem MethodOptions.go).
 func (r *Repository[T, ID]) FillFuncFields(repo interface{}) error {
 	v := reflect.ValueOf(repo).Elem()
 	t := v.Type()
 
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		jpaTag := field.Tag.Get("repo")
-
-		if jpaTag != "@Query" || field.Type.Kind() != reflect.Func {
+		queryName, isQuery, err := parseQueryTag(field.Tag.Get("repo"))
+		if err != nil {
+			return fmt.Errorf("method %s: %w", field.Name, err)
+		}
+		routine, err := parseRoutineTag(field.Tag.Get("repo"), field.Tag.Get("out"))
+		if err != nil {
+			return fmt.Errorf("method %s: %w", field.Name, err)
+		}
+		if !(isQuery || routine != nil) || field.Type.Kind() != reflect.Func {
 			continue
 		}
 		funcType := field.Type
@@ -182,102 +339,178 @@ func (r *Repository[T, ID]) FillFuncFields(repo interface{}) error {
 			return fmt.Errorf("method %s must have context.Context as the first parameter", field.Name)
 		}
 
-		if field.Type.Kind() == reflect.Func {
-			methodName := field.Name
-
-			var qp *QueryParts
-			var err error
-			var isFindAll bool
-
-			if strings.HasPrefix(methodName, "FindAllBy") {
-				isFindAll = true
-				qp, err = parseMethodName("FindBy" + methodName[len("FindAllBy"):])
-			} else if strings.HasPrefix(methodName, "FindBy") {
-				isFindAll = false
-				qp, err = parseMethodName(methodName)
-			} else {
-				return fmt.Errorf("method name %s phải bắt đầu FindBy hoặc FindAllBy", methodName)
+		methodName := field.Name
+
+		// Thủ tục/hàm lưu trữ: `repo:"@Procedure(transfer_funds)" out:"status"`, `repo:"@Function(user_balance)"`
+		if routine != nil {
+			fn, err := r.makeRoutine(routine, funcType, field.Tag)
+			if err != nil {
+				return fmt.Errorf("method %s: %w", methodName, err)
+			}
+			v.Field(i).Set(fn)
+			continue
+		}
+
+		// Câu lệnh có tên: `repo:"@Query(name=FindActiveUsers)"`, nạp bằng repo.WithQueries
+		if queryName != "" {
+			fn, err := r.makeNamed(queryName, funcType, field.Tag)
+			if err != nil {
+				return fmt.Errorf("method %s: %w", methodName, err)
 			}
+			v.Field(i).Set(fn)
+			continue
+		}
+
+		// Biên dịch một lần thành Finder (cột, JOIN, WHERE, số tham số), dùng lại cho mọi lần gọi
+		finder, err := r.newFinder(methodName, field.Tag)
+		if err != nil {
+			return fmt.Errorf("method %s: %w", methodName, err)
+		}
+		if funcType.NumOut() > 0 {
+			finder.result = funcType.Out(0).String()
+		}
+
+		// Hàm aggregate: Count/Sum/Avg/Min/Max...By...GroupBy...
+		if finder.aggregate != nil {
+			fn, err := r.makeAggregate(finder, funcType, field.Tag.Get("optional"))
 			if err != nil {
-				return err
+				return fmt.Errorf("method %s: %w", methodName, err)
 			}
+			v.Field(i).Set(fn)
+			r.finders.add(finder)
+			continue
+		}
 
-			// Kiểm tra kiểu trả về của hàm động
+		// Kiểm tra kiểu trả về của hàm động: (slice|pointer, error) hoặc iter.Seq2[T, error] để stream
+		isStream := funcType.NumOut() == 1 && funcType.Out(0) == reflect.TypeOf(iter.Seq2[T, error](nil))
+		if !isStream {
 			if funcType.NumOut() != 2 {
-				return fmt.Errorf("method %s phải trả về 2 giá trị (result, error)", methodName)
+				return fmt.Errorf("method %s phải trả về 2 giá trị (result, error) hoặc iter.Seq2[T, error]", methodName)
 			}
 			if funcType.Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
 				return fmt.Errorf("method %s output cuối cùng phải là error", methodName)
 			}
-			if isFindAll {
-				if funcType.Out(0).Kind() != reflect.Slice {
-					return fmt.Errorf("method %s phải trả về slice cho FindAllBy", methodName)
-				}
-			} else {
-				if funcType.Out(0).Kind() != reflect.Ptr {
-					return fmt.Errorf("method %s phải trả về pointer cho FindBy", methodName)
-				}
+		}
+		// Kết quả phải đúng []T hoặc *T: MakeFunc trả về giá trị có kiểu khác sẽ panic khi gọi
+		sliceType, ptrType := reflect.TypeOf([]T(nil)), reflect.TypeOf((*T)(nil))
+		if !isStream && funcType.Out(0) != sliceType && funcType.Out(0) != ptrType {
+			return fmt.Errorf("method %s phải trả về %s, %s hoặc iter.Seq2[%s, error], không phải %s",
+				methodName, sliceType, ptrType, sliceType.Elem(), funcType.Out(0))
+		}
+		isFindAll := isStream || funcType.Out(0) == sliceType
+		switch {
+		case strings.HasPrefix(methodName, "FindAll"):
+			if !isFindAll {
+				return fmt.Errorf("method %s phải trả về slice hoặc iter.Seq2 cho FindAllBy", methodName)
+			}
+		case strings.HasPrefix(methodName, "FindBy"):
+			if funcType.Out(0).Kind() != reflect.Ptr {
+				return fmt.Errorf("method %s phải trả về pointer cho FindBy", methodName)
+			}
+		default:
+			if !isFindAll && funcType.Out(0).Kind() != reflect.Ptr {
+				return fmt.Errorf("method %s phải trả về slice, pointer hoặc iter.Seq2", methodName)
 			}
+		}
+		if isStream && len(finder.preloads) > 0 {
+			return fmt.Errorf("method %s: preload không dùng được khi trả về iter.Seq2", methodName)
+		}
 
-			fn := reflect.MakeFunc(funcType, func(args []reflect.Value) (results []reflect.Value) {
-				ctxVal := args[0]
-				ctx := ctxVal.Interface().(context.Context)
+		// Tham số tùy chọn: repo.Opt[T], hoặc pointer khi có tag `optional:"true"`
+		optional, hasOptional, err := parseOptionalTag(field.Tag.Get("optional"), funcType)
+		if err != nil {
+			return fmt.Errorf("method %s: %w", methodName, err)
+		}
 
-				params := make([]interface{}, len(args)-1)
-				for i := 1; i < len(args); i++ {
-					params[i-1] = args[i].Interface()
-				}
+		// Cách đọc từng tham số, chọn một lần khi wiring; vị trí tham số repo.Limit (nếu có), không tính context
+		binders := make([]argBinder, funcType.NumIn())
+		limitIndex := -1
+		for j := 1; j < funcType.NumIn(); j++ {
+			if funcType.In(j) != limitType {
+				binders[j] = paramBinder(funcType.In(j), optional)
+				continue
+			}
+			if limitIndex >= 0 {
+				return fmt.Errorf("method %s chỉ được có một tham số repo.Limit", methodName)
+			}
+			limitIndex = j
+		}
+		numParams := funcType.NumIn() - 1
+		if limitIndex >= 0 {
+			numParams--
+		}
+
+		// Ánh xạ kết quả: các giá trị cố định tạo sẵn một lần
+		var zero, nilErr reflect.Value
+		if !isStream {
+			zero, nilErr = reflect.Zero(funcType.Out(0)), reflect.Zero(funcType.Out(1))
+		}
+		fail := func(err error) []reflect.Value {
+			if isStream {
+				return []reflect.Value{reflect.ValueOf(iter.Seq2[T, error](func(yield func(T, error) bool) {
+					var zero T
+					yield(zero, err)
+				}))}
+			}
+			return []reflect.Value{zero, reflect.ValueOf(err)}
+		}
+
+		fn := reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+			ctx := args[0].Interface().(context.Context)
 
-				// Đếm số lượng ? trong where clause
-				numPlaceholders := strings.Count(strings.Join(qp.WhereClauses, " "), "?")
-				if len(params) != numPlaceholders {
-					results = make([]reflect.Value, funcType.NumOut())
-					err := fmt.Errorf("số lượng tham số truyền vào (%d) không khớp với số lượng điều kiện (%d)", len(params), numPlaceholders)
-					for i := 0; i < funcType.NumOut()-1; i++ {
-						results[i] = reflect.Zero(funcType.Out(i))
-					}
-					results[len(results)-1] = reflect.ValueOf(err)
-					return results
+			limit := 0
+			params := make([]interface{}, 0, numParams)
+			var present []bool
+			if hasOptional {
+				present = make([]bool, 0, numParams)
+			}
+			for i := 1; i < len(args); i++ {
+				if i == limitIndex {
+					limit = int(args[i].Int())
+					continue
+				}
+				value, ok := binders[i](args[i])
+				params = append(params, value)
+				if hasOptional {
+					present = append(present, ok)
 				}
+			}
+
+			// Kiểm tra số tham số, bỏ các điều kiện có tham số tùy chọn vắng mặt
+			qp, params, err := finder.bind(params, present)
+			if err != nil {
+				return fail(err)
+			}
+
+			if isStream {
+				return []reflect.Value{reflect.ValueOf(finder.stream(ctx, qp, params, limit))}
+			}
 
-				dbWithCtx := r.DataSource.WithContext(ctx).Model(new(T))
-
-				if isFindAll {
-					var res []T
-					err := buildGormQuery(dbWithCtx, qp, params).Find(&res).Error
-					if err != nil {
-						results = []reflect.Value{
-							reflect.MakeSlice(funcType.Out(0), 0, 0),
-							reflect.ValueOf(err),
-						}
-						return results
-					}
-					results = []reflect.Value{
-						reflect.ValueOf(res),
-						reflect.Zero(funcType.Out(1)), // nil error
-					}
-				} else {
-					// Khởi tạo một con trỏ tới kiểu của T
-					resPtr := reflect.New(funcType.Out(0).Elem())
-					err := buildGormQuery(dbWithCtx, qp, params).First(resPtr.Interface()).Error
-					if err != nil {
-						results = []reflect.Value{
-							reflect.Zero(funcType.Out(0)),
-							reflect.ValueOf(err),
-						}
-						return results
-					}
-					results = []reflect.Value{
-						resPtr,
-						reflect.Zero(funcType.Out(1)),
-					}
+			if isFindAll {
+				var res []T
+				err := r.call(ctx, finder.calls, func(ctx context.Context) error {
+					return finder.query(ctx, qp, params, limit).Find(&res).Error
+				})
+				if err != nil {
+					return []reflect.Value{reflect.MakeSlice(funcType.Out(0), 0, 0), reflect.ValueOf(err)}
 				}
+				r.track(ctx, res)
+				return []reflect.Value{reflect.ValueOf(res), nilErr}
+			}
 
-				return
+			res := new(T)
+			err = r.call(ctx, finder.calls, func(ctx context.Context) error {
+				return finder.query(ctx, qp, params, limit).First(res).Error
 			})
+			if err != nil {
+				return fail(err)
+			}
+			r.track(ctx, res)
+			return []reflect.Value{reflect.ValueOf(res), nilErr}
+		})
 
-			v.Field(i).Set(fn)
-		}
+		v.Field(i).Set(fn)
+		r.finders.add(finder)
 	}
 	return nil
 }
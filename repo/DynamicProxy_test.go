@@ -0,0 +1,221 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSubject(t *testing.T) {
+	tests := []struct {
+		method  string
+		subject methodSubject
+		rest    string
+		err     string
+	}{
+		{method: "FindByStatus", rest: "Status"},
+		{method: "FindAllByStatus", subject: methodSubject{All: true}, rest: "Status"},
+		{method: "FindDistinctByPartnerId", subject: methodSubject{Distinct: true}, rest: "PartnerId"},
+		{method: "FindFirstByStatus", subject: methodSubject{Limited: true, Limit: 1}, rest: "Status"},
+		{method: "FindTopByStatus", subject: methodSubject{Limited: true, Limit: 1}, rest: "Status"},
+		{method: "FindTop10ByStatus", subject: methodSubject{Limited: true, Limit: 10}, rest: "Status"},
+		{method: "FindFirst3ByStatus", subject: methodSubject{Limited: true, Limit: 3}, rest: "Status"},
+		{method: "FindAllDistinctTop5ByStatus", subject: methodSubject{All: true, Distinct: true, Limited: true, Limit: 5}, rest: "Status"},
+		{method: "FindFirstByOrderByCreatedAtDesc", subject: methodSubject{Limited: true, Limit: 1}, rest: "OrderByCreatedAtDesc"},
+		{method: "FindTop0ByStatus", err: "số lượng bản ghi không hợp lệ"},
+		{method: "Find10ByStatus", err: "chỉ đi sau First hoặc Top"},
+		{method: "FindDistinctAllByStatus", err: "phải có dạng"},
+		{method: "GetByStatus", err: "phải có dạng"},
+		{method: "FindStatus", err: "phải có dạng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			subject, rest, err := parseSubject(tt.method)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *subject != tt.subject || rest != tt.rest {
+				t.Errorf("parseSubject = %+v, %q; muốn %+v, %q", *subject, rest, tt.subject, tt.rest)
+			}
+		})
+	}
+}
+
+func TestSplitKeyword(t *testing.T) {
+	tests := []struct {
+		s, keyword string
+		want       []string
+	}{
+		{"StatusAndPartnerId", "And", []string{"Status", "PartnerId"}},
+		{"AndroidVersion", "And", []string{"AndroidVersion"}},
+		{"NameAndAndroidVersion", "And", []string{"Name", "AndroidVersion"}},
+		{"BrandAndStatus", "And", []string{"Brand", "Status"}},
+		{"Island", "And", []string{"Island"}},
+		{"StatusAnd", "And", []string{"StatusAnd"}},
+		{"OrderIdOrBrand", "Or", []string{"OrderId", "Brand"}},
+		{"OriginOrOrders", "Or", []string{"Origin", "Orders"}},
+		{"ColorOrigin", "Or", []string{"ColorOrigin"}},
+		{"FloorOrName", "Or", []string{"Floor", "Name"}},
+	}
+	for _, tt := range tests {
+		if got := splitKeyword(tt.s, tt.keyword); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitKeyword(%q, %q) = %q, muốn %q", tt.s, tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestParseMethodName(t *testing.T) {
+	tests := []struct {
+		method   string
+		where    string
+		orderBy  string
+		limit    int
+		distinct bool
+		params   int
+		err      string
+	}{
+		{method: "FindByStatus", where: "(status = ?)", params: 1},
+		{method: "FindAllByOrderIdAndBrand", where: "(order_id = ? AND brand = ?)", params: 2},
+		{method: "FindAllByBrandOrOrigin", where: "(brand = ?) OR (origin = ?)", params: 2},
+		{method: "FindAllByOrderIdOrBrandAndStatus", where: "(order_id = ?) OR (brand = ? AND status = ?)", params: 3},
+		{method: "FindAllByTotalBetweenAndDeletedAtIsNull", where: "(total BETWEEN ? AND ? AND deleted_at IS NULL)", params: 2},
+		{method: "FindAllByStatusIn", where: "(status IN (?))", params: 1},
+		{method: "FindAllByStatusOrderByCreatedAtDesc", where: "(status = ?)", orderBy: "created_at DESC", params: 1},
+		{method: "FindAllByStatusOrderByOrderIdLimit5", where: "(status = ?)", orderBy: "order_id ASC", limit: 5, params: 1},
+		{method: "FindAllByStatusOrderByTotalDescLimit10", where: "(status = ?)", orderBy: "total DESC", limit: 10, params: 1},
+		{method: "FindTop3ByStatus", where: "(status = ?)", limit: 3, params: 1},
+		{method: "FindFirstByOrderByCreatedAtDesc", orderBy: "created_at DESC", limit: 1},
+		{method: "FindDistinctByBrand", where: "(brand = ?)", distinct: true, params: 1},
+		{method: "FindFirstByStatusOrderByTotalLimit5", err: "không được dùng đồng thời First/TopN và LimitN"},
+		{method: "FindTop3ByStatusOrderByTotalLimit5", err: "không được dùng đồng thời First/TopN và LimitN"},
+		{method: "FindAllByStatusOrderByTotalLimitX", err: "invalid limit number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			qp, err := parseMethodName(tt.method, snakeCaseColumn)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if qp.where != tt.where || qp.OrderBy != tt.orderBy || qp.Limit != tt.limit || qp.Distinct != tt.distinct {
+				t.Errorf("parseMethodName = where %q, order %q, limit %d, distinct %v", qp.where, qp.OrderBy, qp.Limit, qp.Distinct)
+			}
+			if n := countParams(qp.conditions); n != tt.params {
+				t.Errorf("số tham số = %d, muốn %d", n, tt.params)
+			}
+		})
+	}
+}
+
+type proxyUser struct {
+	ID        uint
+	Status    string
+	PartnerId string
+	Total     int
+}
+
+func TestFillFuncFieldsLimitParam(t *testing.T) {
+	ds := newTestDataSource(t, &proxyUser{})
+	r := NewRepository[proxyUser, uint](ds)
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		if err := r.Insert(ctx, &proxyUser{Status: "active", PartnerId: "p1", Total: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Insert(ctx, &proxyUser{Status: "active", PartnerId: "p2", Total: 9}); err != nil {
+		t.Fatal(err)
+	}
+
+	var repo struct {
+		FindAllByStatusAndPartnerIdOrderByTotalDesc func(ctx context.Context, status string, limit Limit, partnerId string) ([]proxyUser, error) `repo:"@Query"`
+		FindTop2ByStatusOrderByTotal                func(ctx context.Context, status string, limit Limit) ([]proxyUser, error)                   `repo:"@Query"`
+	}
+	if err := r.FillFuncFields(&repo); err != nil {
+		t.Fatal(err)
+	}
+
+	// repo.Limit đứng giữa không tính là tham số điều kiện, 0 giữ giới hạn trong tên hàm
+	users, err := repo.FindAllByStatusAndPartnerIdOrderByTotalDesc(ctx, "active", 2, "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Total != 5 || users[1].Total != 4 {
+		t.Errorf("FindAllByStatusAndPartnerIdOrderByTotalDesc = %+v", users)
+	}
+	if users, err = repo.FindAllByStatusAndPartnerIdOrderByTotalDesc(ctx, "active", 0, "p1"); err != nil || len(users) != 5 {
+		t.Errorf("không giới hạn: %d bản ghi, %v", len(users), err)
+	}
+
+	// repo.Limit ghi đè TopN
+	if users, err = repo.FindTop2ByStatusOrderByTotal(ctx, "active", 0); err != nil || len(users) != 2 {
+		t.Errorf("Top2: %d bản ghi, %v", len(users), err)
+	}
+	if users, err = repo.FindTop2ByStatusOrderByTotal(ctx, "active", 4); err != nil || len(users) != 4 {
+		t.Errorf("Top2 với repo.Limit(4): %d bản ghi, %v", len(users), err)
+	}
+
+	var twoLimits struct {
+		FindAllByStatus func(ctx context.Context, status string, a Limit, b Limit) ([]proxyUser, error) `repo:"@Query"`
+	}
+	if err := r.FillFuncFields(&twoLimits); err == nil || !strings.Contains(err.Error(), "chỉ được có một tham số repo.Limit") {
+		t.Errorf("hai tham số repo.Limit: lỗi = %v", err)
+	}
+
+	var missing struct {
+		FindAllByStatusAndPartnerId func(ctx context.Context, status string, limit Limit) ([]proxyUser, error) `repo:"@Query"`
+	}
+	if err := r.FillFuncFields(&missing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := missing.FindAllByStatusAndPartnerId(ctx, "active", 1); err == nil || !strings.Contains(err.Error(), "không khớp") {
+		t.Errorf("thiếu tham số điều kiện: lỗi = %v", err)
+	}
+}
+
+func TestFillFuncFieldsResultType(t *testing.T) {
+	r := NewRepository[proxyUser, uint](newTestDataSource(t, &proxyUser{}))
+	tests := []struct {
+		name string
+		repo any
+		err  string
+	}{
+		{name: "slice pointer", repo: &struct {
+			FindAllByStatus func(ctx context.Context, status string) ([]*proxyUser, error) `repo:"@Query"`
+		}{}, err: "phải trả về []repo.proxyUser, *repo.proxyUser hoặc iter.Seq2[repo.proxyUser, error], không phải []*repo.proxyUser"},
+		{name: "pointer kiểu khác", repo: &struct {
+			FindByStatus func(ctx context.Context, status string) (*plainItem, error) `repo:"@Query"`
+		}{}, err: "không phải *repo.plainItem"},
+		{name: "slice kiểu khác", repo: &struct {
+			FindAllByStatus func(ctx context.Context, status string) ([]plainItem, error) `repo:"@Query"`
+		}{}, err: "không phải []repo.plainItem"},
+		{name: "FindAll trả về pointer", repo: &struct {
+			FindAllByStatus func(ctx context.Context, status string) (*proxyUser, error) `repo:"@Query"`
+		}{}, err: "phải trả về slice hoặc iter.Seq2 cho FindAllBy"},
+		{name: "đúng kiểu", repo: &struct {
+			FindByStatus    func(ctx context.Context, status string) (*proxyUser, error)  `repo:"@Query"`
+			FindAllByStatus func(ctx context.Context, status string) ([]proxyUser, error) `repo:"@Query"`
+		}{}},
+	}
+	for _, tt := range tests {
+		err := r.FillFuncFields(tt.repo)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: lỗi = %v, muốn chứa %q", tt.name, err, tt.err)
+		}
+	}
+}
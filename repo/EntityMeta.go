@@ -0,0 +1,281 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// entityMeta thông tin về entity đọc từ schema của gorm và tag `repo` trên các field
+type entityMeta struct {
+	schema     *schema.Schema
+	table      string
+	version    *schema.Field // field có tag repo:"@Version"
+	softDelete *softDelete   // field gorm.DeletedAt hoặc có tag repo:"@SoftDelete"
+	audit      audit         // các field @CreatedDate, @LastModifiedDate, @CreatedBy, @LastModifiedBy
+	generator  *idGenerator  // khóa chính có tag repo:"@GeneratedValue(...)"
+}
+
+// parseEntityMeta parse schema của model và các field được đánh dấu bằng tag `repo`
+func parseEntityMeta(db *gorm.DB, model interface{}) (*entityMeta, error) {
+	if db == nil {
+		return nil, fmt.Errorf("repo: DataSource chưa được khởi tạo")
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	meta := &entityMeta{schema: stmt.Schema, table: stmt.Table}
+	for _, field := range stmt.Schema.Fields {
+		tag := field.Tag.Get("repo")
+		switch {
+		case tag == "@Version":
+			if !isIntegerKind(field.IndirectFieldType.Kind()) {
+				return nil, fmt.Errorf("repo: field @Version %s.%s phải là kiểu số nguyên", stmt.Schema.Name, field.Name)
+			}
+			meta.version = field
+		case strings.HasPrefix(tag, "@GeneratedValue"):
+			g, err := parseGeneratedValue(stmt.Schema, field, tag, db.Dialector)
+			if err != nil {
+				return nil, err
+			}
+			meta.generator = g
+		default:
+			if err := meta.audit.parseAuditTag(stmt.Schema, field, tag); err != nil {
+				return nil, err
+			}
+		}
+		sd, err := parseSoftDelete(stmt.Schema, field, tag == "@SoftDelete")
+		if err != nil {
+			return nil, err
+		}
+		if sd != nil {
+			meta.softDelete = sd
+		}
+	}
+	return meta, nil
+}
+
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// primaryKey trả về giá trị khóa chính của entity, khóa nhiều cột trả về []any theo thứ tự các cột khóa
+func (m *entityMeta) primaryKey(ctx context.Context, rv reflect.Value) any {
+	switch len(m.schema.PrimaryFields) {
+	case 0:
+		return nil
+	case 1:
+		v, _ := m.schema.PrimaryFields[0].ValueOf(ctx, rv)
+		return v
+	}
+	values := make([]any, len(m.schema.PrimaryFields))
+	for i, field := range m.schema.PrimaryFields {
+		values[i], _ = field.ValueOf(ctx, rv)
+	}
+	return values
+}
+
+// bumpVersion tăng version của entity lên 1, trả về giá trị cũ để dùng trong WHERE
+func (m *entityMeta) bumpVersion(ctx context.Context, rv reflect.Value) (old any) {
+	old, _ = m.version.ValueOf(ctx, rv)
+	fv := m.version.ReflectValueOf(ctx, rv)
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	switch {
+	case fv.CanInt():
+		fv.SetInt(fv.Int() + 1)
+	case fv.CanUint():
+		fv.SetUint(fv.Uint() + 1)
+	}
+	return old
+}
+
+// restoreVersion trả version về giá trị cũ khi cập nhật thất bại
+func (m *entityMeta) restoreVersion(ctx context.Context, rv reflect.Value, old any) {
+	_ = m.version.Set(ctx, rv, old)
+}
+
+// noRowsError lỗi khi UPDATE/DELETE theo entity không ảnh hưởng dòng nào
+func (m *entityMeta) noRowsError(ctx context.Context, rv reflect.Value, version any) error {
+	if m.version == nil {
+		return ErrNotFound
+	}
+	return &OptimisticLockError{Table: m.table, ID: m.primaryKey(ctx, rv), Version: version}
+}
+
+// versionEq điều kiện WHERE version = ? (IS NULL nếu version là pointer nil)
+func versionEq(m *entityMeta, version any) clause.Eq {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: m.version.DBName}, Value: version}
+}
+
+// assignments chuyển values (map[string]any, *T hoặc T) thành map cột -> giá trị cho câu UPDATE.
+// fields giới hạn các field/cột được ghi; bỏ trống thì map ghi mọi key còn struct chỉ ghi field khác zero.
+func (m *entityMeta) assignments(ctx context.Context, values any, fields []string) (map[string]any, error) {
+	selected := make(map[string]bool, len(fields))
+	for _, name := range fields {
+		field := m.schema.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("repo: không tìm thấy field %s trong %s", name, m.schema.Name)
+		}
+		selected[field.DBName] = true
+	}
+
+	set := make(map[string]any)
+	if mv, ok := values.(map[string]any); ok {
+		for key, value := range mv {
+			field := m.schema.LookUpField(key)
+			if field == nil || field.DBName == "" {
+				return nil, fmt.Errorf("repo: không tìm thấy field %s trong %s", key, m.schema.Name)
+			}
+			if len(selected) == 0 || selected[field.DBName] {
+				set[field.DBName] = value
+			}
+		}
+		return set, nil
+	}
+
+	rv := reflect.ValueOf(values)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Type() != m.schema.ModelType {
+		return nil, fmt.Errorf("repo: giá trị cập nhật phải là map[string]any hoặc %s, nhận %T", m.schema.Name, values)
+	}
+	for _, field := range m.schema.Fields {
+		if field.DBName == "" || field.PrimaryKey || !field.Updatable {
+			continue
+		}
+		value, isZero := field.ValueOf(ctx, rv)
+		if (len(selected) == 0 && !isZero) || selected[field.DBName] {
+			set[field.DBName] = value
+		}
+	}
+	return set, nil
+}
+
+// idValues giá trị các cột khóa chính theo ID. Khóa một cột: ID là giá trị của cột;
+// khóa nhiều cột: ID là struct có field trùng tên field (hoặc tag gorm column) của các cột khóa,
+// hoặc []any theo thứ tự các cột khóa
+func (m *entityMeta) idValues(id any) ([]any, error) {
+	pks := m.schema.PrimaryFields
+	switch {
+	case len(pks) == 0:
+		return nil, fmt.Errorf("repo: %s không có khóa chính", m.schema.Name)
+	case len(pks) == 1:
+		return []any{id}, nil
+	}
+	if values, ok := id.([]any); ok && len(values) == len(pks) {
+		return values, nil
+	}
+	rv := reflect.Indirect(reflect.ValueOf(id))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("repo: %s có khóa nhiều cột, ID phải là struct, nhận %T", m.schema.Name, id)
+	}
+	values := make([]any, len(pks))
+	for i, pk := range pks {
+		fv, ok := keyField(rv, pk)
+		if !ok {
+			return nil, fmt.Errorf("repo: ID %s thiếu field cho cột khóa %s của %s", rv.Type(), pk.DBName, m.schema.Name)
+		}
+		values[i] = fv.Interface()
+	}
+	return values, nil
+}
+
+// keyField tìm field của struct ID ứng với cột khóa: cùng tên field hoặc tag gorm column trùng tên cột
+func keyField(rv reflect.Value, pk *schema.Field) (reflect.Value, bool) {
+	if fv := rv.FieldByName(pk.Name); fv.IsValid() {
+		return fv, true
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if schema.ParseTagSetting(t.Field(i).Tag.Get("gorm"), ";")["COLUMN"] == pk.DBName {
+			return rv.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// idString ID dạng chuỗi (dùng trong lịch sử thay đổi), khóa nhiều cột nối bằng dấu phẩy
+func (m *entityMeta) idString(id any) string {
+	values, err := m.idValues(id)
+	if err != nil {
+		return fmt.Sprint(id)
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (m *entityMeta) keyColumns() []clause.Column {
+	columns := make([]clause.Column, len(m.schema.PrimaryFields))
+	for i, pk := range m.schema.PrimaryFields {
+		columns[i] = clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+	}
+	return columns
+}
+
+// idEq điều kiện WHERE theo khóa chính (mọi cột của khóa nhiều cột)
+func (m *entityMeta) idEq(id any) (clause.Expression, error) {
+	values, err := m.idValues(id)
+	if err != nil {
+		return nil, err
+	}
+	columns := m.keyColumns()
+	if len(columns) == 1 {
+		return clause.Eq{Column: columns[0], Value: values[0]}, nil
+	}
+	exprs := make([]clause.Expression, len(columns))
+	for i, column := range columns {
+		exprs[i] = clause.Eq{Column: column, Value: values[i]}
+	}
+	return clause.And(exprs...), nil
+}
+
+// idIn điều kiện WHERE khóa chính IN (...), khóa nhiều cột dùng row value (a, b) IN ((?, ?), ...)
+func idIn[ID any](m *entityMeta, ids []ID) (clause.Expression, error) {
+	columns := m.keyColumns()
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("repo: %s không có khóa chính", m.schema.Name)
+	}
+	if len(columns) == 1 {
+		values := make([]any, len(ids))
+		for i, id := range ids {
+			values[i] = id
+		}
+		return clause.IN{Column: columns[0], Values: values}, nil
+	}
+	rows := make([][]any, len(ids))
+	for i, id := range ids {
+		values, err := m.idValues(id)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = values
+	}
+	vars := make([]any, 0, len(columns)+1)
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		vars = append(vars, column)
+		placeholders[i] = "?"
+	}
+	vars = append(vars, rows)
+	return clause.Expr{SQL: "(" + strings.Join(placeholders, ",") + ") IN ?", Vars: vars}, nil
+}
@@ -0,0 +1,151 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type enrollment struct {
+	StudentID uint   `gorm:"primaryKey"`
+	CourseID  string `gorm:"primaryKey"`
+	Grade     int
+}
+
+// enrollmentKey ID theo tên field
+type enrollmentKey struct {
+	StudentID uint
+	CourseID  string
+}
+
+// enrollmentColumnKey ID theo tag gorm column, tên field khác entity
+type enrollmentColumnKey struct {
+	Student uint   `gorm:"column:student_id"`
+	Course  string `gorm:"column:course_id"`
+}
+
+func TestIdValues(t *testing.T) {
+	ds := newTestDataSource(t)
+	meta, err := parseEntityMeta(ds.DB, &enrollment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		id   any
+		want string
+		err  string
+	}{
+		{name: "struct", id: enrollmentKey{StudentID: 1, CourseID: "go"}, want: "1,go"},
+		{name: "con trỏ struct", id: &enrollmentKey{StudentID: 2, CourseID: "db"}, want: "2,db"},
+		{name: "gorm column", id: enrollmentColumnKey{Student: 3, Course: "os"}, want: "3,os"},
+		{name: "[]any", id: []any{4, "net"}, want: "4,net"},
+		{name: "thiếu cột", id: struct{ StudentID uint }{5}, err: "thiếu field cho cột khóa course_id"},
+		{name: "không phải struct", id: 6, err: "ID phải là struct"},
+		{name: "[]any sai số cột", id: []any{7}, err: "ID phải là struct"},
+	}
+	for _, tt := range tests {
+		values, err := meta.idValues(tt.id)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: lỗi = %v, muốn chứa %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := meta.idString(tt.id); got != tt.want || len(values) != 2 {
+			t.Errorf("%s: idString = %q, muốn %q", tt.name, got, tt.want)
+		}
+	}
+
+	single, err := parseEntityMeta(ds.DB, &plainItem{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, err := single.idValues(uint(9)); err != nil || len(values) != 1 || values[0] != uint(9) {
+		t.Errorf("khóa một cột: %v, %v", values, err)
+	}
+}
+
+func TestIdInSQL(t *testing.T) {
+	mysqlDB, err := gorm.Open(mysql.New(mysql.Config{DSN: "u:p@tcp(localhost:3306)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbs := map[string]*gorm.DB{
+		"sqlite": newTestDataSource(t).Session(&gorm.Session{DryRun: true}),
+		"mysql":  mysqlDB,
+	}
+	tests := []struct {
+		dialect string
+		model   any
+		ids     []any
+		sql     string
+		vars    int
+	}{
+		{"sqlite", &enrollment{}, []any{enrollmentKey{1, "go"}, enrollmentColumnKey{2, "db"}},
+			"SELECT * FROM `enrollments` WHERE (`enrollments`.`student_id`,`enrollments`.`course_id`) IN ((?,?),(?,?))", 4},
+		{"mysql", &enrollment{}, []any{enrollmentKey{1, "go"}, []any{2, "db"}, enrollmentKey{3, "os"}},
+			"SELECT * FROM `enrollments` WHERE (`enrollments`.`student_id`,`enrollments`.`course_id`) IN ((?,?),(?,?),(?,?))", 6},
+		{"sqlite", &plainItem{}, []any{1, 2, 3},
+			"SELECT * FROM `plain_items` WHERE `plain_items`.`id` IN (?,?,?)", 3},
+		{"mysql", &plainItem{}, []any{1},
+			"SELECT * FROM `plain_items` WHERE `plain_items`.`id` = ?", 1},
+	}
+	for _, tt := range tests {
+		gdb := dbs[tt.dialect]
+		meta, err := parseEntityMeta(gdb, tt.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cond, err := idIn(meta, tt.ids)
+		if err != nil {
+			t.Fatalf("%s %T: %v", tt.dialect, tt.model, err)
+		}
+		stmt := gdb.Model(tt.model).Where(cond).Find(&[]map[string]any{}).Statement
+		if got := stmt.SQL.String(); got != tt.sql || len(stmt.Vars) != tt.vars {
+			t.Errorf("%s:\n  SQL  = %s (%d tham số)\n  muốn %s (%d tham số)", tt.dialect, got, len(stmt.Vars), tt.sql, tt.vars)
+		}
+	}
+
+	meta, err := parseEntityMeta(dbs["sqlite"], &enrollment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idIn(meta, []any{enrollmentKey{1, "go"}, 2}); err == nil {
+		t.Error("ID không phải struct phải lỗi")
+	}
+}
+
+func TestFindAllByCompositeIDs(t *testing.T) {
+	ds := newTestDataSource(t, &enrollment{})
+	r := NewRepository[enrollment, enrollmentKey](ds, IDChunkSize(2))
+	ctx := context.Background()
+	for _, e := range []enrollment{{1, "go", 8}, {1, "db", 7}, {2, "go", 9}} {
+		if err := r.Insert(ctx, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := r.FindAllByIDs(ctx, []enrollmentKey{{1, "go"}, {2, "go"}, {2, "db"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("FindAllByIDs = %+v", list)
+	}
+	n, err := r.DeleteAllByIDs(ctx, []enrollmentKey{{1, "db"}, {2, "go"}, {3, "go"}})
+	if err != nil || n != 2 {
+		t.Errorf("DeleteAllByIDs = %d, %v", n, err)
+	}
+	if count, _ := r.Count(ctx); count != 1 {
+		t.Errorf("còn %d bản ghi, muốn 1", count)
+	}
+}
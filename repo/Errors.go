@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound không có bản ghi nào khớp, chính là gorm.ErrRecordNotFound để errors.Is dùng được cho cả hai
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// ErrLockRequiresTx khóa bi quan (SELECT ... FOR UPDATE) chỉ có tác dụng trong transaction
+var ErrLockRequiresTx = errors.New("repo: lock chỉ dùng được trong transaction, hãy gọi qua DataSource.Transactional")
+
+// errNoFieldsToUpdate Patch/UpdateFields không có field nào để ghi
+var errNoFieldsToUpdate = errors.New("repo: không có field nào để cập nhật")
+
+// errNilProbe FindByExample/PageByExample nhận probe nil (đọc cả bảng thì truyền &T{})
+var errNilProbe = errors.New("repo: probe của Query by Example là nil, dùng &T{} để không lọc")
+
+// ErrOptimisticLock bản ghi đã bị transaction khác cập nhật/xóa (version không khớp)
+var ErrOptimisticLock = errors.New("repo: optimistic lock, bản ghi đã bị thay đổi")
+
+// OptimisticLockError chi tiết lỗi optimistic lock, errors.Is(err, ErrOptimisticLock) trả về true
+type OptimisticLockError struct {
+	Table   string
+	ID      any
+	Version any
+}
+
+func (e *OptimisticLockError) Error() string {
+	return fmt.Sprintf("%v: %s id=%v version=%v", ErrOptimisticLock, e.Table, e.ID, e.Version)
+}
+
+func (e *OptimisticLockError) Is(target error) bool {
+	return target == ErrOptimisticLock
+}
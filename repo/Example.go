@@ -0,0 +1,238 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExampleRepository truy vấn theo entity mẫu (Query by Example), *Repository[T, ID] cài đặt sẵn
+type ExampleRepository[T any, ID comparable] interface {
+	FindByExample(ctx context.Context, probe *T, matcher ...MatchOption) ([]T, error)
+	PageByExample(ctx context.Context, page int, pageSize int, probe *T, matcher ...MatchOption) (*Page[T], error)
+}
+
+var _ ExampleRepository[struct{}, int] = (*Repository[struct{}, int])(nil)
+
+// StringMatch cách so khớp field kiểu chuỗi của probe
+type StringMatch int
+
+const (
+	MatchExact      StringMatch = iota // col = value
+	MatchContains                      // col LIKE %value%
+	MatchStartsWith                    // col LIKE value%
+	MatchEndsWith                      // col LIKE %value
+)
+
+// MatchOption cấu hình cách dựng điều kiện từ probe của FindByExample/PageByExample
+type MatchOption func(m *exampleMatcher)
+
+type exampleMatcher struct {
+	any        bool
+	strings    StringMatch
+	ignoreCase bool
+	fields     map[string]StringMatch // field -> cách so khớp riêng
+	caseFields []string               // field so khớp không phân biệt hoa thường
+	ignored    []string
+	nulls      bool     // mọi field zero đều thành điều kiện
+	nullFields []string // các field zero vẫn thành điều kiện
+}
+
+// MatchAny nối các điều kiện bằng OR thay vì AND
+func MatchAny() MatchOption {
+	return func(m *exampleMatcher) {
+		m.any = true
+	}
+}
+
+// MatchStrings cách so khớp mặc định cho mọi field chuỗi
+func MatchStrings(match StringMatch) MatchOption {
+	return func(m *exampleMatcher) {
+		m.strings = match
+	}
+}
+
+// MatchField cách so khớp riêng cho một field chuỗi (tên field hoặc tên cột)
+func MatchField(field string, match StringMatch) MatchOption {
+	return func(m *exampleMatcher) {
+		if m.fields == nil {
+			m.fields = map[string]StringMatch{}
+		}
+		m.fields[field] = match
+	}
+}
+
+// IgnoreCase so khớp chuỗi không phân biệt hoa thường cho các field, không truyền field thì áp dụng cho mọi field chuỗi
+func IgnoreCase(fields ...string) MatchOption {
+	return func(m *exampleMatcher) {
+		if len(fields) == 0 {
+			m.ignoreCase = true
+		}
+		m.caseFields = append(m.caseFields, fields...)
+	}
+}
+
+// IgnoreFields bỏ qua các field của probe dù có giá trị
+func IgnoreFields(fields ...string) MatchOption {
+	return func(m *exampleMatcher) {
+		m.ignored = append(m.ignored, fields...)
+	}
+}
+
+// IncludeNulls field có giá trị zero/nil vẫn thành điều kiện (nil -> IS NULL), mặc định bị bỏ qua.
+// Không truyền field thì áp dụng cho mọi field trừ khóa chính.
+func IncludeNulls(fields ...string) MatchOption {
+	return func(m *exampleMatcher) {
+		if len(fields) == 0 {
+			m.nulls = true
+		}
+		m.nullFields = append(m.nullFields, fields...)
+	}
+}
+
+// exampleCondition dựng điều kiện từ các field của probe, trả về nil khi probe không có điều kiện nào
+func exampleCondition(ctx context.Context, meta *entityMeta, probe any, opts []MatchOption) (clause.Expression, error) {
+	if rv := reflect.ValueOf(probe); !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return nil, errNilProbe
+	}
+	m := &exampleMatcher{}
+	for _, o := range opts {
+		o(m)
+	}
+	ignored, err := meta.columnSet(m.ignored)
+	if err != nil {
+		return nil, err
+	}
+	caseFields, err := meta.columnSet(m.caseFields)
+	if err != nil {
+		return nil, err
+	}
+	nullFields, err := meta.columnSet(m.nullFields)
+	if err != nil {
+		return nil, err
+	}
+	matches := make(map[string]StringMatch, len(m.fields))
+	for name, match := range m.fields {
+		column, err := meta.column(name)
+		if err != nil {
+			return nil, err
+		}
+		matches[column] = match
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(probe))
+	var exprs []clause.Expression
+	for _, field := range meta.schema.Fields {
+		if field.DBName == "" || !field.Readable || ignored[field.DBName] {
+			continue
+		}
+		value, isZero := field.ValueOf(ctx, rv)
+		if isZero && !nullFields[field.DBName] && !(m.nulls && !field.PrimaryKey) {
+			continue
+		}
+		column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+		if isNullValue(value) {
+			exprs = append(exprs, clause.Eq{Column: column, Value: nil})
+			continue
+		}
+		if field.IndirectFieldType.Kind() != reflect.String {
+			exprs = append(exprs, clause.Eq{Column: column, Value: value})
+			continue
+		}
+		match, ok := matches[field.DBName]
+		if !ok {
+			match = m.strings
+		}
+		exprs = append(exprs, stringCondition(column, reflect.Indirect(reflect.ValueOf(value)).String(), match, m.ignoreCase || caseFields[field.DBName]))
+	}
+	switch {
+	case len(exprs) == 0:
+		return nil, nil
+	case m.any:
+		return clause.Or(exprs...), nil
+	}
+	return clause.And(exprs...), nil
+}
+
+// likeEscaper thoát ký tự đặc biệt của LIKE, dùng ESCAPE '!' để chạy giống nhau trên mọi DB
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func stringCondition(column clause.Column, value string, match StringMatch, ignoreCase bool) clause.Expression {
+	if match == MatchExact {
+		if ignoreCase {
+			return clause.Expr{SQL: "LOWER(?) = LOWER(?)", Vars: []any{column, value}}
+		}
+		return clause.Eq{Column: column, Value: value}
+	}
+	pattern := likeEscaper.Replace(value)
+	switch match {
+	case MatchContains:
+		pattern = "%" + pattern + "%"
+	case MatchStartsWith:
+		pattern += "%"
+	case MatchEndsWith:
+		pattern = "%" + pattern
+	}
+	if ignoreCase {
+		return clause.Expr{SQL: "LOWER(?) LIKE LOWER(?) ESCAPE '!'", Vars: []any{column, pattern}}
+	}
+	return clause.Expr{SQL: "? LIKE ? ESCAPE '!'", Vars: []any{column, pattern}}
+}
+
+// columnSet tập tên cột của các field (tên field hoặc tên cột)
+func (m *entityMeta) columnSet(fields []string) (map[string]bool, error) {
+	set := make(map[string]bool, len(fields))
+	for _, name := range fields {
+		column, err := m.column(name)
+		if err != nil {
+			return nil, err
+		}
+		set[column] = true
+	}
+	return set, nil
+}
+
+// exampleScope điều kiện Query by Example gắn vào truy vấn
+func (r *Repository[T, ID]) exampleScope(ctx context.Context, probe *T, opts []MatchOption) (func(*gorm.DB) *gorm.DB, error) {
+	meta, err := r.entity()
+	if err != nil {
+		return nil, err
+	}
+	cond, err := exampleCondition(ctx, meta, probe, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(q *gorm.DB) *gorm.DB {
+		if cond == nil {
+			return q
+		}
+		return q.Where(cond)
+	}, nil
+}
+
+// FindByExample tìm entity theo probe: mỗi field khác zero của probe là một điều kiện (mặc định nối bằng AND, so khớp chính xác).
+// Probe nil trả về lỗi, &T{} là không lọc.
+func (r *Repository[T, ID]) FindByExample(ctx context.Context, probe *T, matcher ...MatchOption) ([]T, error) {
+	scope, err := r.exampleScope(ctx, probe, matcher)
+	if err != nil {
+		return nil, err
+	}
+	var items []T
+	if err := scope(r.query(ctx)).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	r.track(ctx, items)
+	return items, nil
+}
+
+// PageByExample phân trang kết quả FindByExample
+func (r *Repository[T, ID]) PageByExample(ctx context.Context, page int, pageSize int, probe *T, matcher ...MatchOption) (*Page[T], error) {
+	scope, err := r.exampleScope(ctx, probe, matcher)
+	if err != nil {
+		return nil, err
+	}
+	return r.page(ctx, page, pageSize, scope)
+}
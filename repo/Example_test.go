@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFindByExampleNilProbe(t *testing.T) {
+	r := NewRepository[proxyUser, uint](newTestDataSource(t, &proxyUser{}))
+	ctx := context.Background()
+	if err := r.Insert(ctx, &proxyUser{Status: "active", PartnerId: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.FindByExample(ctx, nil); !errors.Is(err, errNilProbe) {
+		t.Errorf("FindByExample(nil): lỗi = %v", err)
+	}
+	if _, err := r.PageByExample(ctx, 1, 10, nil, MatchAny()); !errors.Is(err, errNilProbe) {
+		t.Errorf("PageByExample(nil): lỗi = %v", err)
+	}
+	// Probe rỗng: không có điều kiện, đọc cả bảng
+	if users, err := r.FindByExample(ctx, &proxyUser{}); err != nil || len(users) != 1 {
+		t.Errorf("FindByExample(&T{}) = %d bản ghi, %v", len(users), err)
+	}
+}
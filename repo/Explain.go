@@ -0,0 +1,458 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xhkzeroone/go-database/db"
+	"gorm.io/gorm"
+)
+
+// Statement câu SQL của một hàm dynamic sinh bằng gorm DryRun (không chạy truy vấn), Vars theo thứ tự placeholder
+type Statement struct {
+	Method string `json:"method"`
+	SQL    string `json:"sql"`
+	Vars   []any  `json:"vars,omitempty"`
+}
+
+// ExplainResult kế hoạch thực thi của một hàm dynamic: Plan là cây thao tác đã chuẩn hóa giữa các dialect,
+// Raw là kết quả gốc của EXPLAIN (JSON với postgres/mysql, dạng cây text với EXPLAIN ANALYZE của mysql)
+type ExplainResult struct {
+	Statement
+	Dialect       string        `json:"dialect"`
+	Analyze       bool          `json:"analyze,omitempty"`
+	Plan          []ExplainNode `json:"plan"`
+	PlanningTime  float64       `json:"planningTime,omitempty"`  // ms, postgres
+	ExecutionTime float64       `json:"executionTime,omitempty"` // ms, postgres với ANALYZE
+	Raw           string        `json:"raw,omitempty"`
+}
+
+// ExplainNode một bước trong kế hoạch thực thi, các số đo vắng mặt khi dialect không cung cấp
+type ExplainNode struct {
+	Operation  string        `json:"operation"`
+	Table      string        `json:"table,omitempty"`
+	Index      string        `json:"index,omitempty"`
+	Condition  string        `json:"condition,omitempty"`
+	Cost       float64       `json:"cost,omitempty"`       // chi phí ước lượng
+	Rows       float64       `json:"rows,omitempty"`       // số dòng ước lượng
+	ActualTime float64       `json:"actualTime,omitempty"` // ms, chỉ với ANALYZE
+	ActualRows float64       `json:"actualRows,omitempty"` // chỉ với ANALYZE
+	Children   []ExplainNode `json:"children,omitempty"`
+}
+
+// DryRun câu SQL của hàm dynamic method với tham số mẫu args (như khi gọi hàm, kể cả repo.Opt),
+// không truyền args thì mọi tham số là NULL
+func (r *Repository[T, ID]) DryRun(ctx context.Context, method string, args ...any) (Statement, error) {
+	f, err := r.wiredFinder(method)
+	if err != nil {
+		return Statement{}, err
+	}
+	return f.DryRun(ctx, args...)
+}
+
+// Statements câu SQL (tham số NULL) của mọi hàm dynamic đã wiring, theo tên hàm
+func (r *Repository[T, ID]) Statements(ctx context.Context) ([]Statement, error) {
+	finders := r.finders.all()
+	stmts := make([]Statement, 0, len(finders))
+	for _, f := range finders {
+		stmt, err := f.DryRun(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts, nil
+}
+
+// Explain chạy EXPLAIN câu SQL của hàm dynamic method với tham số mẫu args, xem DryRun
+func (r *Repository[T, ID]) Explain(ctx context.Context, method string, args ...any) (*ExplainResult, error) {
+	f, err := r.wiredFinder(method)
+	if err != nil {
+		return nil, err
+	}
+	return f.Explain(ctx, args...)
+}
+
+// ExplainAnalyze như Explain nhưng thực thi truy vấn để có thời gian và số dòng thực tế (postgres, mysql)
+func (r *Repository[T, ID]) ExplainAnalyze(ctx context.Context, method string, args ...any) (*ExplainResult, error) {
+	f, err := r.wiredFinder(method)
+	if err != nil {
+		return nil, err
+	}
+	return f.ExplainAnalyze(ctx, args...)
+}
+
+func (r *Repository[T, ID]) wiredFinder(method string) (*Finder[T, ID], error) {
+	f, ok := r.finders.get(method)
+	if !ok {
+		return nil, fmt.Errorf("repo: method %s chưa được wiring (FillFuncFields, Finder hoặc repogen)", method)
+	}
+	return f, nil
+}
+
+// DryRun câu SQL của một lần gọi với tham số mẫu args, không truyền args thì mọi tham số là NULL.
+// Lock được đưa vào câu SQL kể cả khi ctx không có transaction.
+func (f *Finder[T, ID]) DryRun(ctx context.Context, args ...any) (Statement, error) {
+	if len(args) == 0 {
+		args = make([]any, f.params)
+	}
+	params, present := unwrapArgs(args)
+	base := f.repo.reader(ctx).Session(&gorm.Session{DryRun: true})
+	var q *gorm.DB
+	if f.aggregate != nil {
+		if len(params) != f.params {
+			return Statement{}, fmt.Errorf("số lượng tham số truyền vào (%d) không khớp với số lượng điều kiện (%d)", len(params), f.params)
+		}
+		q = f.aggregate.build(f.hints.apply(base), f.joins, params, present).Find(&[]map[string]any{})
+	} else {
+		qp, params, err := f.bind(params, present)
+		if err != nil {
+			return Statement{}, err
+		}
+		q = f.build(f.repo.configured(base), qp, params, 0)
+		if f.single() {
+			q = q.First(new(T))
+		} else {
+			q = q.Find(new([]T))
+		}
+	}
+	if q.Error != nil {
+		return Statement{}, fmt.Errorf("method %s: %w", f.name, q.Error)
+	}
+	return Statement{Method: f.name, SQL: q.Statement.SQL.String(), Vars: q.Statement.Vars}, nil
+}
+
+// single hàm trả về một entity (One, SELECT ... LIMIT 1): theo kiểu kết quả khi wiring bằng FillFuncFields,
+// với Finder tạo trực tiếp thì theo tên hàm (FindBy, FindFirstBy)
+func (f *Finder[T, ID]) single() bool {
+	if f.result != "" {
+		return strings.HasPrefix(f.result, "*")
+	}
+	return !strings.HasPrefix(f.name, "FindAll") && !f.qp.Distinct && f.qp.Limit <= 1
+}
+
+// Explain chạy EXPLAIN câu SQL của hàm với tham số mẫu args (xem DryRun), theo timeout/readonly của hàm
+func (f *Finder[T, ID]) Explain(ctx context.Context, args ...any) (*ExplainResult, error) {
+	return f.explain(ctx, false, args)
+}
+
+// ExplainAnalyze như Explain nhưng thực thi truy vấn để có thời gian và số dòng thực tế (postgres, mysql).
+// Hàm có lock thực sự khóa các dòng khớp nên phải gọi trong transaction, không thì trả về ErrLockRequiresTx.
+func (f *Finder[T, ID]) ExplainAnalyze(ctx context.Context, args ...any) (*ExplainResult, error) {
+	return f.explain(ctx, true, args)
+}
+
+func (f *Finder[T, ID]) explain(ctx context.Context, analyze bool, args []any) (*ExplainResult, error) {
+	if _, inTx := db.TxFromContext(ctx); analyze && f.lock != nil && !inTx {
+		return nil, fmt.Errorf("method %s: %w", f.name, ErrLockRequiresTx)
+	}
+	stmt, err := f.DryRun(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	res := &ExplainResult{Statement: stmt, Analyze: analyze}
+	err = f.repo.call(ctx, f.calls, func(ctx context.Context) error {
+		conn := f.repo.Conn(ctx)
+		res.Dialect = conn.Dialector.Name()
+		return explainStatement(ctx, conn, res)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("method %s: %w", f.name, err)
+	}
+	return res, nil
+}
+
+// explainStatement chạy EXPLAIN theo dialect của conn và chuẩn hóa kết quả vào res
+func explainStatement(ctx context.Context, conn *gorm.DB, res *ExplainResult) error {
+	var prefix string
+	switch {
+	case res.Dialect == "postgres" && res.Analyze:
+		prefix = "EXPLAIN (ANALYZE, FORMAT JSON) "
+	case res.Dialect == "postgres":
+		prefix = "EXPLAIN (FORMAT JSON) "
+	case res.Dialect == "mysql" && res.Analyze:
+		// EXPLAIN ANALYZE của mysql (8.0.18+) chỉ trả về dạng cây text
+		prefix = "EXPLAIN ANALYZE "
+	case res.Dialect == "mysql":
+		prefix = "EXPLAIN FORMAT=JSON "
+	case res.Dialect == "sqlite" && !res.Analyze:
+		prefix = "EXPLAIN QUERY PLAN "
+	case res.Dialect == "sqlite":
+		return fmt.Errorf("sqlite không hỗ trợ EXPLAIN ANALYZE")
+	default:
+		return fmt.Errorf("EXPLAIN chưa hỗ trợ trên %s", res.Dialect)
+	}
+
+	// Câu SQL đã có placeholder của dialect ($1 với postgres), chạy thẳng trên connection thay vì qua Raw
+	rows, err := conn.Statement.ConnPool.QueryContext(ctx, prefix+res.SQL, res.Vars...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if res.Dialect == "sqlite" {
+		res.Plan, err = sqlitePlan(rows)
+		return err
+	}
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	res.Raw = strings.Join(lines, "\n")
+	switch {
+	case res.Dialect == "postgres":
+		return postgresPlan(res)
+	case res.Analyze:
+		res.Plan = mysqlTreePlan(res.Raw)
+		return nil
+	default:
+		return mysqlPlan(res)
+	}
+}
+
+// postgresNode một node trong EXPLAIN (FORMAT JSON) của postgres
+type postgresNode struct {
+	NodeType        string         `json:"Node Type"`
+	RelationName    string         `json:"Relation Name"`
+	IndexName       string         `json:"Index Name"`
+	IndexCond       string         `json:"Index Cond"`
+	HashCond        string         `json:"Hash Cond"`
+	MergeCond       string         `json:"Merge Cond"`
+	JoinFilter      string         `json:"Join Filter"`
+	Filter          string         `json:"Filter"`
+	TotalCost       float64        `json:"Total Cost"`
+	PlanRows        float64        `json:"Plan Rows"`
+	ActualTotalTime float64        `json:"Actual Total Time"`
+	ActualRows      float64        `json:"Actual Rows"`
+	Plans           []postgresNode `json:"Plans"`
+}
+
+func (n postgresNode) node() ExplainNode {
+	var conds []string
+	for _, cond := range []string{n.IndexCond, n.HashCond, n.MergeCond, n.JoinFilter, n.Filter} {
+		if cond != "" {
+			conds = append(conds, cond)
+		}
+	}
+	node := ExplainNode{
+		Operation:  n.NodeType,
+		Table:      n.RelationName,
+		Index:      n.IndexName,
+		Condition:  strings.Join(conds, " AND "),
+		Cost:       n.TotalCost,
+		Rows:       n.PlanRows,
+		ActualTime: n.ActualTotalTime,
+		ActualRows: n.ActualRows,
+	}
+	for _, child := range n.Plans {
+		node.Children = append(node.Children, child.node())
+	}
+	return node
+}
+
+func postgresPlan(res *ExplainResult) error {
+	var out []struct {
+		Plan          postgresNode `json:"Plan"`
+		PlanningTime  float64      `json:"Planning Time"`
+		ExecutionTime float64      `json:"Execution Time"`
+	}
+	if err := json.Unmarshal([]byte(res.Raw), &out); err != nil {
+		return fmt.Errorf("đọc EXPLAIN của postgres: %w", err)
+	}
+	for _, p := range out {
+		res.Plan = append(res.Plan, p.Plan.node())
+		res.PlanningTime += p.PlanningTime
+		res.ExecutionTime += p.ExecutionTime
+	}
+	return nil
+}
+
+func mysqlPlan(res *ExplainResult) error {
+	var out map[string]any
+	if err := json.Unmarshal([]byte(res.Raw), &out); err != nil {
+		return fmt.Errorf("đọc EXPLAIN của mysql: %w", err)
+	}
+	res.Plan = mysqlNodes(out)
+	return nil
+}
+
+// mysqlNodes chuyển EXPLAIN FORMAT=JSON của mysql thành cây: mỗi "table" là một node truy cập bảng,
+// các khối lồng nhau (query_block, ordering_operation, nested_loop, ...) là node cha
+func mysqlNodes(m map[string]any) []ExplainNode {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var nodes []ExplainNode
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case map[string]any:
+			if key == "table" {
+				nodes = append(nodes, mysqlTable(v))
+				continue
+			}
+			if children := mysqlNodes(v); len(children) > 0 {
+				nodes = append(nodes, ExplainNode{Operation: key, Cost: mysqlCost(v, "query_cost"), Children: children})
+			}
+		case []any:
+			var children []ExplainNode
+			for _, item := range v {
+				if item, ok := item.(map[string]any); ok {
+					children = append(children, mysqlNodes(item)...)
+				}
+			}
+			if len(children) > 0 {
+				nodes = append(nodes, ExplainNode{Operation: key, Children: children})
+			}
+		}
+	}
+	return nodes
+}
+
+func mysqlTable(t map[string]any) ExplainNode {
+	str := func(key string) string {
+		s, _ := t[key].(string)
+		return s
+	}
+	node := ExplainNode{
+		Operation: str("access_type"),
+		Table:     str("table_name"),
+		Index:     str("key"),
+		Condition: str("attached_condition"),
+		Cost:      mysqlCost(t, "prefix_cost"),
+	}
+	if rows, ok := t["rows_examined_per_scan"].(float64); ok {
+		node.Rows = rows
+	}
+	// Bảng dẫn xuất, subquery gắn kèm
+	for key, v := range t {
+		if v, ok := v.(map[string]any); ok && key != "cost_info" {
+			node.Children = append(node.Children, mysqlNodes(v)...)
+		}
+	}
+	return node
+}
+
+// mysqlCost đọc cost_info.<key>, mysql ghi chi phí dạng chuỗi
+func mysqlCost(m map[string]any, key string) float64 {
+	info, _ := m["cost_info"].(map[string]any)
+	s, _ := info[key].(string)
+	cost, _ := strconv.ParseFloat(s, 64)
+	return cost
+}
+
+var (
+	mysqlTreeLine   = regexp.MustCompile(`^(\s*)-> (.*?)(?:\s+\(cost=([0-9.e+]+) rows=([0-9.e+]+)\))?(?:\s+\(actual time=[0-9.e+]+\.\.([0-9.e+]+) rows=([0-9.e+]+) loops=[0-9]+\)|\s+\(never executed\))?$`)
+	mysqlTreeTable  = regexp.MustCompile(` on (\S+)`)
+	mysqlTreeIndex  = regexp.MustCompile(` using (\S+)`)
+	sqliteTable     = regexp.MustCompile(`^(?:SCAN|SEARCH) (\S+)`)
+	sqliteIndex     = regexp.MustCompile(`USING (?:COVERING )?INDEX (\S+)`)
+	sqliteCondition = regexp.MustCompile(`\((.*)\)$`)
+)
+
+// planTree node tạm khi dựng cây từ các dòng của EXPLAIN
+type planTree struct {
+	node     ExplainNode
+	children []*planTree
+}
+
+func (t *planTree) explainNode() ExplainNode {
+	node := t.node
+	for _, child := range t.children {
+		node.Children = append(node.Children, child.explainNode())
+	}
+	return node
+}
+
+func explainNodes(roots []*planTree) []ExplainNode {
+	nodes := make([]ExplainNode, len(roots))
+	for i, root := range roots {
+		nodes[i] = root.explainNode()
+	}
+	return nodes
+}
+
+// mysqlTreePlan đọc cây text của EXPLAIN ANALYZE (mysql), cấp của node theo độ thụt lề
+func mysqlTreePlan(raw string) []ExplainNode {
+	type level struct {
+		indent int
+		tree   *planTree
+	}
+	var roots []*planTree
+	var stack []level
+	for _, line := range strings.Split(raw, "\n") {
+		m := mysqlTreeLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		t := &planTree{node: ExplainNode{Operation: m[2]}}
+		t.node.Cost, _ = strconv.ParseFloat(m[3], 64)
+		t.node.Rows, _ = strconv.ParseFloat(m[4], 64)
+		t.node.ActualTime, _ = strconv.ParseFloat(m[5], 64)
+		t.node.ActualRows, _ = strconv.ParseFloat(m[6], 64)
+		if table := mysqlTreeTable.FindStringSubmatch(m[2]); table != nil {
+			t.node.Table = table[1]
+		}
+		if index := mysqlTreeIndex.FindStringSubmatch(m[2]); index != nil {
+			t.node.Index = index[1]
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= len(m[1]) {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, t)
+		} else {
+			parent := stack[len(stack)-1].tree
+			parent.children = append(parent.children, t)
+		}
+		stack = append(stack, level{len(m[1]), t})
+	}
+	return explainNodes(roots)
+}
+
+// sqlitePlan dựng cây từ các dòng (id, parent, notused, detail) của EXPLAIN QUERY PLAN
+func sqlitePlan(rows *sql.Rows) ([]ExplainNode, error) {
+	var roots []*planTree
+	byID := map[int]*planTree{}
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			return nil, err
+		}
+		t := &planTree{node: ExplainNode{Operation: detail}}
+		if table := sqliteTable.FindStringSubmatch(detail); table != nil {
+			t.node.Table = table[1]
+		}
+		if index := sqliteIndex.FindStringSubmatch(detail); index != nil {
+			t.node.Index = index[1]
+			if cond := sqliteCondition.FindStringSubmatch(detail); cond != nil {
+				t.node.Condition = cond[1]
+			}
+		}
+		// Node cha luôn xuất hiện trước node con
+		if p, ok := byID[parent]; ok {
+			p.children = append(p.children, t)
+		} else {
+			roots = append(roots, t)
+		}
+		byID[id] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return explainNodes(roots), nil
+}
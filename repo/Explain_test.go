@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readFixture kết quả EXPLAIN thật lưu trong testdata/explain
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "explain", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestMysqlTreePlan(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []ExplainNode
+	}{
+		{fixture: "mysql_analyze.txt", want: []ExplainNode{{
+			Operation: "Limit: 20 row(s)", Cost: 2.75, Rows: 3, ActualTime: 0.0702, ActualRows: 2,
+			Children: []ExplainNode{
+				{Operation: "Sort: proxy_users.total DESC, limit input to 20 row(s) per chunk", Cost: 2.75, Rows: 3, ActualTime: 0.0692, ActualRows: 2,
+					Children: []ExplainNode{{
+						Operation: "Filter: ((proxy_users.total > 10) or <in_optimizer>(proxy_users.partner_id,proxy_users.partner_id in (select #2)))",
+						Cost:      2.75, Rows: 3, ActualTime: 0.0538, ActualRows: 2,
+						Children: []ExplainNode{
+							{Operation: "Index lookup on proxy_users using idx_proxy_users_status (status='active')",
+								Table: "proxy_users", Index: "idx_proxy_users_status", Cost: 2.75, Rows: 3, ActualTime: 0.0497, ActualRows: 3},
+							// Subquery không chạy: không có số đo thực tế
+							{Operation: "Select #2 (subquery in condition; run only once)",
+								Children: []ExplainNode{{Operation: "Table scan on partners", Table: "partners", Cost: 0.35, Rows: 1}}},
+						},
+					}}},
+			},
+		}}},
+		{fixture: "mysql_join_analyze.txt", want: []ExplainNode{{
+			Operation: "Nested loop inner join", Cost: 1250, Rows: 1020, ActualTime: 1.64, ActualRows: 998,
+			Children: []ExplainNode{
+				{Operation: "Table scan on o", Table: "o", Cost: 103, Rows: 1020, ActualTime: 0.552, ActualRows: 1000},
+				{Operation: "Single-row index lookup on p using PRIMARY (id=o.partner_id)", Table: "p", Index: "PRIMARY",
+					Cost: 0.25, Rows: 1, ActualTime: 0.000946, ActualRows: 0.998},
+			},
+		}}},
+	}
+	for _, tt := range tests {
+		if got := mysqlTreePlan(readFixture(t, tt.fixture)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nmuốn %+v", tt.fixture, got, tt.want)
+		}
+	}
+}
+
+func TestMysqlPlan(t *testing.T) {
+	res := &ExplainResult{Raw: readFixture(t, "mysql.json")}
+	if err := mysqlPlan(res); err != nil {
+		t.Fatal(err)
+	}
+	want := []ExplainNode{{Operation: "query_block", Cost: 0.6, Children: []ExplainNode{{
+		Operation: "ordering_operation",
+		Children: []ExplainNode{{Operation: "ref", Table: "proxy_users", Index: "idx_proxy_users_status",
+			Condition: "(`app`.`proxy_users`.`partner_id` = 'p1')", Cost: 0.6, Rows: 2}},
+	}}}}
+	if !reflect.DeepEqual(res.Plan, want) {
+		t.Errorf("\n got %+v\nmuốn %+v", res.Plan, want)
+	}
+}
+
+func TestPostgresPlan(t *testing.T) {
+	res := &ExplainResult{Raw: readFixture(t, "postgres_analyze.json")}
+	if err := postgresPlan(res); err != nil {
+		t.Fatal(err)
+	}
+	want := []ExplainNode{{
+		Operation: "Limit", Cost: 8.19, Rows: 1, ActualTime: 0.043, ActualRows: 2,
+		Children: []ExplainNode{{
+			Operation: "Sort", Cost: 8.19, Rows: 1, ActualTime: 0.041, ActualRows: 2,
+			Children: []ExplainNode{{
+				Operation: "Hash Join", Condition: "(proxy_users.partner_id = p.id)", Cost: 8.17, Rows: 1, ActualTime: 0.031, ActualRows: 2,
+				Children: []ExplainNode{
+					{Operation: "Index Scan", Table: "proxy_users", Index: "idx_proxy_users_status",
+						Condition: "(status = 'active'::text) AND (total > 10)", Cost: 7.1, Rows: 2, ActualTime: 0.011, ActualRows: 3},
+					{Operation: "Hash", Cost: 1.02, Rows: 2, ActualTime: 0.007, ActualRows: 2,
+						Children: []ExplainNode{{Operation: "Seq Scan", Table: "partners", Cost: 1.02, Rows: 2, ActualTime: 0.004, ActualRows: 2}}},
+				},
+			}},
+		}},
+	}}
+	if !reflect.DeepEqual(res.Plan, want) {
+		t.Errorf("\n got %+v\nmuốn %+v", res.Plan, want)
+	}
+	if res.PlanningTime != 0.215 || res.ExecutionTime != 0.067 {
+		t.Errorf("PlanningTime = %v, ExecutionTime = %v", res.PlanningTime, res.ExecutionTime)
+	}
+	if err := postgresPlan(&ExplainResult{Raw: "QUERY PLAN"}); err == nil {
+		t.Error("postgresPlan nhận kết quả không phải JSON")
+	}
+}
+
+func TestSqlitePlan(t *testing.T) {
+	// Dựng lại các dòng (id, parent, notused, detail) của fixture bằng VALUES
+	ds := newTestDataSource(t)
+	var values []string
+	var vars []any
+	for _, line := range strings.Split(readFixture(t, "sqlite.txt"), "\n") {
+		cols := strings.SplitN(line, "|", 4)
+		values = append(values, "(?, ?, ?, ?)")
+		vars = append(vars, cols[0], cols[1], cols[2], cols[3])
+	}
+	rows, err := ds.DB.Raw("SELECT CAST(column1 AS INTEGER), CAST(column2 AS INTEGER), CAST(column3 AS INTEGER), column4 FROM (VALUES "+
+		strings.Join(values, ", ")+")", vars...).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got, err := sqlitePlan(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ExplainNode{
+		{Operation: "SEARCH proxy_users USING INDEX idx_proxy_users_status (status=?)", Table: "proxy_users",
+			Index: "idx_proxy_users_status", Condition: "status=?"},
+		{Operation: "LIST SUBQUERY 1", Children: []ExplainNode{{Operation: "SCAN partners", Table: "partners"}}},
+		{Operation: "USE TEMP B-TREE FOR ORDER BY"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\n got %+v\nmuốn %+v", got, want)
+	}
+}
+
+func TestExplainSqlite(t *testing.T) {
+	ds := newTestDataSource(t, &proxyUser{})
+	if err := ds.DB.Exec("CREATE INDEX idx_proxy_users_status ON proxy_users(status)").Error; err != nil {
+		t.Fatal(err)
+	}
+	r := NewRepository[proxyUser, uint](ds)
+	ctx := context.Background()
+	if _, err := r.Finder("FindAllByStatusOrderByTotalDesc", ""); err != nil {
+		t.Fatal(err)
+	}
+	res, err := r.Explain(ctx, "FindAllByStatusOrderByTotalDesc", "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Dialect != "sqlite" || len(res.Plan) == 0 || res.Plan[0].Index != "idx_proxy_users_status" {
+		t.Errorf("Explain = %+v", res)
+	}
+	if _, err := r.ExplainAnalyze(ctx, "FindAllByStatusOrderByTotalDesc", "active"); err == nil || !strings.Contains(err.Error(), "không hỗ trợ EXPLAIN ANALYZE") {
+		t.Errorf("ExplainAnalyze trên sqlite: lỗi = %v", err)
+	}
+}
+
+func TestExplainAnalyzeLock(t *testing.T) {
+	ds := newTestDataSource(t, &proxyUser{})
+	r := NewRepository[proxyUser, uint](ds)
+	ctx := context.Background()
+	if _, err := r.Finder("FindByStatus", `lock:"update"`); err != nil {
+		t.Fatal(err)
+	}
+	// Không có transaction: không được chạy SELECT ... FOR UPDATE
+	if _, err := r.ExplainAnalyze(ctx, "FindByStatus", "active"); !errors.Is(err, ErrLockRequiresTx) {
+		t.Errorf("ngoài transaction: lỗi = %v, muốn ErrLockRequiresTx", err)
+	}
+	// EXPLAIN không thực thi truy vấn nên không cần transaction
+	if _, err := r.Explain(ctx, "FindByStatus", "active"); err != nil {
+		t.Errorf("Explain: %v", err)
+	}
+	err := ds.Transactional(ctx, func(ctx context.Context) error {
+		_, err := r.ExplainAnalyze(ctx, "FindByStatus", "active")
+		return err
+	})
+	if errors.Is(err, ErrLockRequiresTx) || err == nil || !strings.Contains(err.Error(), "không hỗ trợ EXPLAIN ANALYZE") {
+		t.Errorf("trong transaction: lỗi = %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// joinAliasPrefix tiền tố alias cho các bảng join sinh ra từ tên hàm,
+// tránh trùng với alias mà gorm dùng cho Joins("Partner")
+const joinAliasPrefix = "by_"
+
+// fieldPath đường đi từ entity gốc tới một cột, qua 0..n quan hệ
+type fieldPath struct {
+	relations []*schema.Relationship
+	field     *schema.Field
+}
+
+func (p fieldPath) String() string {
+	names := make([]string, 0, len(p.relations)+1)
+	for _, rel := range p.relations {
+		names = append(names, rel.Name)
+	}
+	return strings.Join(append(names, p.field.Name), ".")
+}
+
+// fieldResolver resolve tên field trong tên hàm dynamic thành cột SQL dựa trên schema của gorm.
+// Hỗ trợ đi qua quan hệ (ví dụ: PartnerCountryCode -> Partner.CountryCode) và ghi nhận các JOIN cần thiết.
+type fieldResolver struct {
+	stmt    *gorm.Statement
+	joins   []string
+	aliases map[string]bool
+	joined  map[string]bool // các cột thuộc bảng join
+	toMany  bool            // có join qua quan hệ has-many/many2many, cần loại bản ghi trùng
+	sources map[string]columnSource
+}
+
+// columnSource bảng thật (không phải alias join) và tên cột của một cột đã resolve, dùng khi kiểm tra index
+type columnSource struct {
+	table  string
+	column string
+}
+
+func newFieldResolver(db *gorm.DB, model interface{}) (*fieldResolver, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return &fieldResolver{
+		stmt:    stmt,
+		aliases: map[string]bool{},
+		joined:  map[string]bool{},
+		sources: map[string]columnSource{},
+	}, nil
+}
+
+// resolve trả về tên cột đã quote kèm bảng/alias cho field trong tên hàm.
+// Có thể dùng dấu "_" để chỉ rõ đường đi qua quan hệ (ví dụ: Partner_CountryCode).
+func (fr *fieldResolver) resolve(name string) (string, error) {
+	var paths []fieldPath
+	if strings.Contains(name, "_") {
+		if p, ok := explicitPath(fr.stmt.Schema, strings.Split(name, "_")); ok {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		paths = findPaths(fr.stmt.Schema, name)
+	}
+
+	switch len(paths) {
+	case 0:
+		return "", fmt.Errorf("không tìm thấy field %s trong %s", name, fr.stmt.Schema.Name)
+	case 1:
+	default:
+		candidates := make([]string, len(paths))
+		for i, p := range paths {
+			candidates[i] = p.String()
+		}
+		sort.Strings(candidates)
+		return "", fmt.Errorf("field %s không rõ ràng trong %s (%s), dùng dấu _ để chỉ rõ quan hệ, ví dụ: Partner_CountryCode",
+			name, fr.stmt.Schema.Name, strings.Join(candidates, ", "))
+	}
+
+	path := paths[0]
+	table, source := fr.stmt.Table, fr.stmt.Table
+	for i, rel := range path.relations {
+		alias := joinAlias(path.relations[:i+1])
+		if !fr.aliases[alias] {
+			fr.aliases[alias] = true
+			fr.joins = append(fr.joins, fr.joinClause(table, alias, rel))
+		}
+		if rel.Type == schema.HasMany || rel.Type == schema.Many2Many {
+			fr.toMany = true
+		}
+		table, source = alias, rel.FieldSchema.Table
+	}
+
+	column := fr.stmt.Quote(table + "." + path.field.DBName)
+	fr.sources[column] = columnSource{table: source, column: path.field.DBName}
+	if len(path.relations) > 0 {
+		fr.joined[column] = true
+	}
+	return column, nil
+}
+
+// apply gắn các JOIN đã ghi nhận vào QueryParts
+func (fr *fieldResolver) apply(qp *QueryParts) error {
+	if len(fr.joins) == 0 {
+		return nil
+	}
+	if fr.toMany && qp.OrderBy != "" {
+		column := qp.OrderBy[:strings.LastIndex(qp.OrderBy, " ")]
+		if fr.joined[column] {
+			return fmt.Errorf("không hỗ trợ OrderBy theo cột của bảng join khi có quan hệ has-many/many2many")
+		}
+	}
+	qp.Joins = fr.joins
+	qp.Select = fr.stmt.Quote(fr.stmt.Table) + ".*"
+	qp.Distinct = qp.Distinct || fr.toMany
+	return nil
+}
+
+// joinClause sinh câu LEFT JOIN từ bảng cha tới quan hệ rel với alias cho trước
+func (fr *fieldResolver) joinClause(parent, alias string, rel *schema.Relationship) string {
+	quote := fr.stmt.Quote
+	target := quote(rel.FieldSchema.Table) + " " + quote(alias)
+
+	if rel.Type == schema.Many2Many {
+		joinAlias := alias + "__join"
+		var ownConds, targetConds []string
+		for _, ref := range rel.References {
+			if ref.OwnPrimaryKey {
+				ownConds = append(ownConds, fmt.Sprintf("%s = %s",
+					quote(joinAlias+"."+ref.ForeignKey.DBName), quote(parent+"."+ref.PrimaryKey.DBName)))
+			} else {
+				targetConds = append(targetConds, fmt.Sprintf("%s = %s",
+					quote(alias+"."+ref.PrimaryKey.DBName), quote(joinAlias+"."+ref.ForeignKey.DBName)))
+			}
+		}
+		return fmt.Sprintf("LEFT JOIN %s %s ON %s LEFT JOIN %s ON %s",
+			quote(rel.JoinTable.Table), quote(joinAlias), strings.Join(ownConds, " AND "),
+			target, strings.Join(targetConds, " AND "))
+	}
+
+	conds := make([]string, 0, len(rel.References))
+	for _, ref := range rel.References {
+		switch {
+		case ref.OwnPrimaryKey:
+			conds = append(conds, fmt.Sprintf("%s = %s",
+				quote(alias+"."+ref.ForeignKey.DBName), quote(parent+"."+ref.PrimaryKey.DBName)))
+		case ref.PrimaryValue != "":
+			conds = append(conds, fmt.Sprintf("%s = '%s'",
+				quote(alias+"."+ref.ForeignKey.DBName), strings.ReplaceAll(ref.PrimaryValue, "'", "''")))
+		default:
+			conds = append(conds, fmt.Sprintf("%s = %s",
+				quote(alias+"."+ref.PrimaryKey.DBName), quote(parent+"."+ref.ForeignKey.DBName)))
+		}
+	}
+	return fmt.Sprintf("LEFT JOIN %s ON %s", target, strings.Join(conds, " AND "))
+}
+
+func joinAlias(relations []*schema.Relationship) string {
+	names := make([]string, len(relations))
+	for i, rel := range relations {
+		names[i] = rel.Name
+	}
+	return joinAliasPrefix + strings.Join(names, "__")
+}
+
+// lookupField tìm field có cột theo tên field hoặc theo snake_case của tên
+func lookupField(s *schema.Schema, name string) *schema.Field {
+	if f, ok := s.FieldsByName[name]; ok && f.DBName != "" {
+		return f
+	}
+	if f, ok := s.FieldsByDBName[toSnakeCase(name)]; ok {
+		return f
+	}
+	return nil
+}
+
+// findPaths tìm mọi cách hiểu tên field. Cột trực tiếp được ưu tiên (PartnerID là cột partner_id,
+// không phải Partner.ID), nếu không có thì thử đi qua các quan hệ có tên là tiền tố của name.
+func findPaths(s *schema.Schema, name string) []fieldPath {
+	if f := lookupField(s, name); f != nil {
+		return []fieldPath{{field: f}}
+	}
+	var paths []fieldPath
+	for relName, rel := range s.Relationships.Relations {
+		if len(name) <= len(relName) || !strings.HasPrefix(name, relName) {
+			continue
+		}
+		for _, p := range findPaths(rel.FieldSchema, name[len(relName):]) {
+			p.relations = append([]*schema.Relationship{rel}, p.relations...)
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// explicitPath resolve đường đi đã tách sẵn bằng "_" (Partner_Country_Code)
+func explicitPath(s *schema.Schema, segments []string) (fieldPath, bool) {
+	var p fieldPath
+	for _, seg := range segments[:len(segments)-1] {
+		rel, ok := s.Relationships.Relations[seg]
+		if !ok {
+			return p, false
+		}
+		p.relations = append(p.relations, rel)
+		s = rel.FieldSchema
+	}
+	p.field = lookupField(s, segments[len(segments)-1])
+	return p, p.field != nil
+}
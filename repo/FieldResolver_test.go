@@ -0,0 +1,203 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type resolverCountry struct {
+	ID   uint
+	Code string
+}
+
+type resolverPartner struct {
+	ID          uint
+	Name        string
+	CountryCode string
+	CountryID   uint
+	Country     resolverCountry
+}
+
+type resolverRegion struct {
+	ID   uint
+	Code string
+}
+
+type resolverOrder struct {
+	ID     uint
+	UserID uint
+	Amount int
+}
+
+type resolverRole struct {
+	ID   uint
+	Name string
+}
+
+type resolverUser struct {
+	ID               uint
+	Name             string
+	PartnerID        uint
+	Partner          resolverPartner
+	PartnerCountryID uint
+	PartnerCountry   resolverRegion
+	Orders           []resolverOrder `gorm:"foreignKey:UserID"`
+	Roles            []resolverRole  `gorm:"many2many:resolver_user_roles"`
+}
+
+func newResolverRepository(t *testing.T) *Repository[resolverUser, uint] {
+	ds := newTestDataSource(t, &resolverCountry{}, &resolverPartner{}, &resolverRegion{}, &resolverOrder{}, &resolverRole{}, &resolverUser{})
+	return NewRepository[resolverUser, uint](ds)
+}
+
+func TestFieldResolver(t *testing.T) {
+	r := newResolverRepository(t)
+	tests := []struct {
+		method   string
+		where    string
+		joins    []string
+		distinct bool
+		err      []string
+	}{
+		{
+			// Cột trực tiếp partner_id được ưu tiên hơn Partner.ID
+			method: "FindAllByPartnerID",
+			where:  "(`resolver_users`.`partner_id` = ?)",
+		},
+		{
+			// partner_country_id thay vì PartnerCountry.ID hay Partner.CountryID
+			method: "FindAllByPartnerCountryID",
+			where:  "(`resolver_users`.`partner_country_id` = ?)",
+		},
+		{
+			method: "FindAllByPartnerName",
+			where:  "(`by_Partner`.`name` = ?)",
+			joins:  []string{"LEFT JOIN `resolver_partners` `by_Partner` ON `by_Partner`.`id` = `resolver_users`.`partner_id`"},
+		},
+		{
+			method: "FindAllByPartnerCountryCode",
+			err:    []string{"không rõ ràng", "Partner.CountryCode", "PartnerCountry.Code", "Partner_CountryCode"},
+		},
+		{
+			method: "FindAllByPartner_CountryCode",
+			where:  "(`by_Partner`.`country_code` = ?)",
+			joins:  []string{"LEFT JOIN `resolver_partners` `by_Partner` ON `by_Partner`.`id` = `resolver_users`.`partner_id`"},
+		},
+		{
+			method: "FindAllByPartnerCountry_Code",
+			where:  "(`by_PartnerCountry`.`code` = ?)",
+			joins:  []string{"LEFT JOIN `resolver_regions` `by_PartnerCountry` ON `by_PartnerCountry`.`id` = `resolver_users`.`partner_country_id`"},
+		},
+		{
+			method: "FindAllByPartner_Country_CodeAndPartnerName",
+			where:  "(`by_Partner__Country`.`code` = ? AND `by_Partner`.`name` = ?)",
+			joins: []string{
+				"LEFT JOIN `resolver_partners` `by_Partner` ON `by_Partner`.`id` = `resolver_users`.`partner_id`",
+				"LEFT JOIN `resolver_countries` `by_Partner__Country` ON `by_Partner__Country`.`id` = `by_Partner`.`country_id`",
+			},
+		},
+		{
+			method:   "FindAllByOrdersAmountGreaterThan",
+			where:    "(`by_Orders`.`amount` > ?)",
+			joins:    []string{"LEFT JOIN `resolver_orders` `by_Orders` ON `by_Orders`.`user_id` = `resolver_users`.`id`"},
+			distinct: true,
+		},
+		{
+			method: "FindAllByRolesName",
+			where:  "(`by_Roles`.`name` = ?)",
+			joins: []string{"LEFT JOIN `resolver_user_roles` `by_Roles__join` ON `by_Roles__join`.`resolver_user_id` = `resolver_users`.`id` " +
+				"LEFT JOIN `resolver_roles` `by_Roles` ON `by_Roles`.`id` = `by_Roles__join`.`resolver_role_id`"},
+			distinct: true,
+		},
+		{
+			method: "FindAllByOrdersAmountOrderByOrdersAmount",
+			err:    []string{"không hỗ trợ OrderBy theo cột của bảng join"},
+		},
+		{
+			method: "FindAllByPartner_Nope",
+			err:    []string{"không tìm thấy field Partner_Nope"},
+		},
+		{
+			method: "FindAllByNope",
+			err:    []string{"không tìm thấy field Nope"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			f, err := r.newFinder(tt.method, "")
+			if len(tt.err) > 0 {
+				if err == nil {
+					t.Fatal("muốn lỗi")
+				}
+				for _, want := range tt.err {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("lỗi %q không chứa %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.qp.where != tt.where {
+				t.Errorf("where = %s, muốn %s", f.qp.where, tt.where)
+			}
+			if !reflect.DeepEqual(f.qp.Joins, tt.joins) {
+				t.Errorf("joins = %q, muốn %q", f.qp.Joins, tt.joins)
+			}
+			if f.qp.Distinct != tt.distinct {
+				t.Errorf("distinct = %v, muốn %v", f.qp.Distinct, tt.distinct)
+			}
+			if len(tt.joins) > 0 && f.qp.Select != "`resolver_users`.*" {
+				t.Errorf("select = %s", f.qp.Select)
+			}
+		})
+	}
+}
+
+func TestFieldResolverQuery(t *testing.T) {
+	r := newResolverRepository(t)
+	ctx := context.Background()
+	partner := resolverPartner{Name: "acme", CountryCode: "VN"}
+	if err := r.DB.Create(&partner).Error; err != nil {
+		t.Fatal(err)
+	}
+	users := []resolverUser{
+		{Name: "a", PartnerID: partner.ID, Orders: []resolverOrder{{Amount: 20}, {Amount: 30}}, Roles: []resolverRole{{Name: "admin"}}},
+		{Name: "b", Orders: []resolverOrder{{Amount: 5}}},
+	}
+	if err := r.DB.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Hai đơn hàng thỏa điều kiện nhưng user chỉ xuất hiện một lần nhờ DISTINCT
+	byOrders, err := r.Finder("FindAllByOrdersAmountGreaterThan", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := byOrders.All(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Name != "a" {
+		t.Errorf("FindAllByOrdersAmountGreaterThan = %+v", found)
+	}
+
+	byCountry, err := r.Finder("FindAllByPartner_CountryCode", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err = byCountry.All(ctx, 0, "VN"); err != nil || len(found) != 1 || found[0].Name != "a" {
+		t.Errorf("FindAllByPartner_CountryCode = %+v, %v", found, err)
+	}
+
+	byRole, err := r.Finder("FindAllByRolesName", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err = byRole.All(ctx, 0, "admin"); err != nil || len(found) != 1 || found[0].Name != "a" {
+		t.Errorf("FindAllByRolesName = %+v, %v", found, err)
+	}
+}
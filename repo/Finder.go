@@ -0,0 +1,340 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Finder hàm dynamic đã phân tích sẵn từ tên hàm và tag (cột, JOIN, điều kiện, preload, lock), gọi trực tiếp
+// không qua reflect.MakeFunc. FillFuncFields và code sinh bởi cmd/repogen dùng chung Finder.
+type Finder[T any, ID comparable] struct {
+	repo     *Repository[T, ID]
+	name     string
+	qp       *QueryParts
+	preloads []preload
+	lock     *lockSpec
+
+	aggregate *aggregateQuery // hàm Count/Sum/Avg/Min/Max
+	joins     []string        // JOIN của hàm aggregate
+
+	columns []string // các cột đã resolve, xem Plan
+	params  int      // số tham số điều kiện
+	result  string   // kiểu kết quả khi wiring bằng FillFuncFields
+
+	calls callTags       // timeout, readonly
+	hints optimizerHints // hint, hint_<dialect>
+
+	sources map[string]columnSource // bảng/cột thật của các cột đã resolve, xem CheckIndexes
+}
+
+// Finder phân tích hàm dynamic methodName với tag của field (`preload`, `fetch`, `lock`, `timeout`, `readonly`, `hint`), ví dụ:
+//
+//	f, err := r.Finder("FindAllByStatusOrderByCreatedAtDesc", `preload:"Partner"`)
+//	users, err := f.All(ctx, 0, "active")
+func (r *Repository[T, ID]) Finder(methodName string, tag reflect.StructTag) (*Finder[T, ID], error) {
+	f, err := r.newFinder(methodName, tag)
+	if err != nil {
+		return nil, fmt.Errorf("method %s: %w", methodName, err)
+	}
+	r.finders.add(f)
+	return f, nil
+}
+
+func (r *Repository[T, ID]) newFinder(methodName string, tag reflect.StructTag) (*Finder[T, ID], error) {
+	f := &Finder[T, ID]{repo: r, name: methodName}
+	resolve := snakeCaseColumn
+	var resolver *fieldResolver
+	if r.DataSource != nil && r.DB != nil {
+		var err error
+		if resolver, err = newFieldResolver(r.DB, new(T)); err != nil {
+			return nil, err
+		}
+		resolve = resolver.resolve
+	}
+	resolve = recordColumns(resolve, &f.columns)
+	if resolver != nil {
+		f.sources = resolver.sources
+	}
+
+	// Tag áp dụng cho mỗi lần gọi: `timeout:"500ms"`, `readonly:"true"`, `hint_mysql:"MAX_EXECUTION_TIME(500)"`
+	var err error
+	if f.calls, err = parseCallTags(tag); err != nil {
+		return nil, err
+	}
+	if f.hints, err = parseHintTags(tag); err != nil {
+		return nil, err
+	}
+
+	// Hàm aggregate: Count/Sum/Avg/Min/Max...By...GroupBy...
+	kind := MethodKind(methodName)
+	if kind == PlanAggregate {
+		if tag.Get("preload") != "" || tag.Get("lock") != "" {
+			return nil, fmt.Errorf("preload/lock không dùng được với hàm aggregate")
+		}
+		aq, err := parseAggregateMethod(methodName, resolve)
+		if err != nil {
+			return nil, err
+		}
+		if resolver != nil {
+			if resolver.toMany {
+				return nil, fmt.Errorf("aggregate không hỗ trợ field qua quan hệ has-many/many2many (dòng bị nhân bản khi join)")
+			}
+			f.joins = resolver.joins
+		}
+		f.aggregate = aq
+		f.params = countParams(aq.conditions)
+		return f, nil
+	}
+
+	if kind != PlanFind {
+		return nil, fmt.Errorf("method name %s phải bắt đầu FindBy, FindAllBy hoặc Count/Sum/Avg/Min/Max", methodName)
+	}
+	qp, err := parseMethodName(methodName, resolve)
+	if err != nil {
+		return nil, err
+	}
+	if resolver != nil {
+		if err := resolver.apply(qp); err != nil {
+			return nil, err
+		}
+	}
+	f.qp = qp
+	f.params = countParams(qp.conditions)
+
+	// Nạp sẵn quan hệ: `preload:"Orders,Partner"`, `fetch:"join"` để nạp bằng JOIN
+	if f.preloads, err = parsePreloadTag(tag.Get("preload"), tag.Get("fetch")); err != nil {
+		return nil, err
+	}
+	if resolver != nil {
+		for _, p := range f.preloads {
+			if err := validatePreload(resolver.stmt.Schema, p); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Khóa bi quan: `lock:"update,skip_locked"`, chỉ chạy được trong transaction
+	if f.lock, err = parseLockTag(tag.Get("lock")); err != nil {
+		return nil, err
+	}
+	if f.lock != nil {
+		if f.calls.readonly {
+			return nil, fmt.Errorf("lock không dùng được với readonly")
+		}
+		if qp.Distinct {
+			return nil, fmt.Errorf("lock không dùng được với DISTINCT")
+		}
+		if r.DataSource != nil && r.DB != nil {
+			if _, err := f.lock.clause(r.Dialector.Name()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return f, nil
+}
+
+// Name tên hàm dynamic
+func (f *Finder[T, ID]) Name() string {
+	return f.name
+}
+
+// Args tham số của một lần gọi Finder đã tách sẵn repo.Opt: Values theo thứ tự điều kiện trong tên hàm,
+// Present (nil: mọi tham số có mặt) cho biết tham số tùy chọn nào có giá trị.
+// Code sinh ra bởi repogen biết kiểu từng tham số nên tạo thẳng Args, không đóng gói qua ...any rồi unwrapArgs.
+type Args struct {
+	Values  []any
+	Present []bool
+}
+
+// argsOf tách args của One/All/Stream/Aggregate thành Args
+func argsOf(args []any) Args {
+	params, present := unwrapArgs(args)
+	return Args{Values: params, Present: present}
+}
+
+// One trả về entity đầu tiên thỏa điều kiện (FindBy, FindFirstBy), args theo thứ tự điều kiện trong tên hàm,
+// tham số repo.Opt[T] không có giá trị làm điều kiện tương ứng bị bỏ
+func (f *Finder[T, ID]) One(ctx context.Context, args ...any) (*T, error) {
+	return f.OneArgs(ctx, argsOf(args))
+}
+
+// OneArgs như One với tham số đã tách sẵn
+func (f *Finder[T, ID]) OneArgs(ctx context.Context, args Args) (*T, error) {
+	if f.qp == nil {
+		return nil, fmt.Errorf("method %s là hàm aggregate, dùng Aggregate", f.name)
+	}
+	qp, params, err := f.bind(args.Values, args.Present)
+	if err != nil {
+		return nil, err
+	}
+	res := new(T)
+	err = f.repo.call(ctx, f.calls, func(ctx context.Context) error {
+		return f.query(ctx, qp, params, 0).First(res).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.repo.track(ctx, res)
+	return res, nil
+}
+
+// All trả về các entity thỏa điều kiện, limit > 0 ghi đè giới hạn trong tên hàm (TopN, LimitN)
+func (f *Finder[T, ID]) All(ctx context.Context, limit Limit, args ...any) ([]T, error) {
+	return f.AllArgs(ctx, limit, argsOf(args))
+}
+
+// AllArgs như All với tham số đã tách sẵn
+func (f *Finder[T, ID]) AllArgs(ctx context.Context, limit Limit, args Args) ([]T, error) {
+	if f.qp == nil {
+		return nil, fmt.Errorf("method %s là hàm aggregate, dùng Aggregate", f.name)
+	}
+	qp, params, err := f.bind(args.Values, args.Present)
+	if err != nil {
+		return nil, err
+	}
+	var res []T
+	err = f.repo.call(ctx, f.calls, func(ctx context.Context) error {
+		return f.query(ctx, qp, params, int(limit)).Find(&res).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.repo.track(ctx, res)
+	return res, nil
+}
+
+// Stream duyệt các entity thỏa điều kiện mà không nạp hết vào bộ nhớ, xem Repository.StreamAll
+func (f *Finder[T, ID]) Stream(ctx context.Context, limit Limit, args ...any) iter.Seq2[T, error] {
+	return f.StreamArgs(ctx, limit, argsOf(args))
+}
+
+// StreamArgs như Stream với tham số đã tách sẵn
+func (f *Finder[T, ID]) StreamArgs(ctx context.Context, limit Limit, args Args) iter.Seq2[T, error] {
+	var qp *QueryParts
+	var params []any
+	var err error
+	switch {
+	case f.qp == nil:
+		err = fmt.Errorf("method %s là hàm aggregate, dùng Aggregate", f.name)
+	case len(f.preloads) > 0:
+		err = fmt.Errorf("method %s: preload không dùng được khi stream", f.name)
+	default:
+		qp, params, err = f.bind(args.Values, args.Present)
+	}
+	if err != nil {
+		return func(yield func(T, error) bool) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+	return f.stream(ctx, qp, params, int(limit))
+}
+
+// Aggregate chạy hàm Count/Sum/Avg/Min/Max và ghi kết quả vào dest (pointer tới giá trị đơn, map hoặc slice struct)
+func (f *Finder[T, ID]) Aggregate(ctx context.Context, dest any, args ...any) error {
+	return f.AggregateArgs(ctx, dest, argsOf(args))
+}
+
+// AggregateArgs như Aggregate với tham số đã tách sẵn
+func (f *Finder[T, ID]) AggregateArgs(ctx context.Context, dest any, args Args) error {
+	if f.aggregate == nil {
+		return fmt.Errorf("method %s không phải hàm aggregate", f.name)
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("method %s: dest phải là pointer khác nil", f.name)
+	}
+	if err := f.aggregate.checkResult(rv.Type().Elem()); err != nil {
+		return fmt.Errorf("method %s: %w", f.name, err)
+	}
+	if len(args.Values) != f.params {
+		return fmt.Errorf("số lượng tham số truyền vào (%d) không khớp với số lượng điều kiện (%d)", len(args.Values), f.params)
+	}
+	return f.repo.call(ctx, f.calls, func(ctx context.Context) error {
+		return f.aggregate.scan(f.aggregate.build(f.hints.apply(f.repo.reader(ctx)), f.joins, args.Values, args.Present), rv)
+	})
+}
+
+// bind kiểm tra số tham số và bỏ các điều kiện có tham số tùy chọn vắng mặt (present nil: đủ mọi tham số)
+func (f *Finder[T, ID]) bind(params []any, present []bool) (*QueryParts, []any, error) {
+	if len(params) != f.params {
+		return nil, nil, fmt.Errorf("số lượng tham số truyền vào (%d) không khớp với số lượng điều kiện (%d)", len(params), f.params)
+	}
+	if present == nil {
+		return f.qp, params, nil
+	}
+	bound := *f.qp
+	bound.WhereClauses, params = bindConditions(f.qp.conditions, params, present)
+	bound.where = strings.Join(bound.WhereClauses, " OR ")
+	return &bound, params, nil
+}
+
+// query truy vấn đọc của một lần gọi, limit > 0 ghi đè giới hạn trong tên hàm
+func (f *Finder[T, ID]) query(ctx context.Context, qp *QueryParts, params []any, limit int) *gorm.DB {
+	return f.build(f.repo.query(ctx), qp, params, limit)
+}
+
+// build gắn hint, preload, lock của hàm và điều kiện của một lần gọi vào q
+func (f *Finder[T, ID]) build(q *gorm.DB, qp *QueryParts, params []any, limit int) *gorm.DB {
+	q = applyLock(applyPreloads(f.hints.apply(q), f.preloads), f.lock)
+	return buildGormQuery(q, qp, params, f.limit(limit))
+}
+
+// stream duyệt kết quả trong phạm vi timeout/readonly của hàm, áp dụng cho cả vòng lặp
+func (f *Finder[T, ID]) stream(ctx context.Context, qp *QueryParts, params []any, limit int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		stopped := false
+		err := f.repo.call(ctx, f.calls, func(ctx context.Context) error {
+			seq := f.repo.stream(ctx, func(ctx context.Context) *gorm.DB {
+				return buildGormQuery(applyLock(f.hints.apply(f.repo.query(ctx)), f.lock), qp, params, f.limit(limit))
+			})
+			for v, err := range seq {
+				if !yield(v, err) {
+					stopped = true
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil && !stopped {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+func (f *Finder[T, ID]) limit(limit int) int {
+	if limit > 0 {
+		return limit
+	}
+	return f.qp.Limit
+}
+
+// unwrapArgs tách giá trị của các tham số repo.Opt[T], present nil khi không có tham số tùy chọn nào
+func unwrapArgs(args []any) ([]any, []bool) {
+	var params []any
+	var present []bool
+	for i, arg := range args {
+		o, ok := arg.(optionalParam)
+		if !ok {
+			continue
+		}
+		if present == nil {
+			params = append([]any(nil), args...)
+			present = make([]bool, len(args))
+			for j := range present {
+				present[j] = true
+			}
+		}
+		params[i], present[i] = o.optional()
+	}
+	if present == nil {
+		return args, nil
+	}
+	return params, present
+}
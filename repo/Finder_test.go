@@ -0,0 +1,170 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// newDryRunRepository repository trên sqlite ở chế độ DryRun: dựng câu SQL nhưng không chạy,
+// benchmark chỉ đo phần việc của Finder và gorm
+func newDryRunRepository(b *testing.B) *Repository[proxyUser, uint] {
+	ds := newTestDataSource(b, &proxyUser{})
+	ds.DB = ds.DB.Session(&gorm.Session{DryRun: true})
+	return NewRepository[proxyUser, uint](ds)
+}
+
+// BenchmarkFinderArgs so sánh Finder.All(...any) với Finder.AllArgs mà code sinh ra bởi repogen gọi
+func BenchmarkFinderArgs(b *testing.B) {
+	r := newDryRunRepository(b)
+	ctx := context.Background()
+	f, err := r.Finder("FindAllByStatusAndPartnerIdOrderByTotalDesc", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	status, partnerId := "active", (*string)(nil)
+
+	b.Run("variadic", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := f.All(ctx, 0, status, "p1"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("args", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := f.AllArgs(ctx, 0, Args{Values: []any{status, "p1"}}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("variadic_opt", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := f.All(ctx, 0, Some(status), OptOf(partnerId)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("args_opt", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v1, ok1 := Some(status).Get()
+			v2, ok2 := OptOf(partnerId).Get()
+			if _, err := f.AllArgs(ctx, 0, Args{Values: []any{v1, v2}, Present: []bool{ok1, ok2}}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// baselineFind đường gọi trước khi có Finder: phân tích tên hàm, đếm "?" bằng strings.Join/strings.Count
+// và ghép WHERE trong mỗi lần gọi
+func baselineFind(r *Repository[proxyUser, uint], ctx context.Context, method string, dest any, first bool, params ...any) error {
+	qp, err := parseMethodName(method, snakeCaseColumn)
+	if err != nil {
+		return err
+	}
+	if n := strings.Count(strings.Join(qp.WhereClauses, " "), "?"); n != len(params) {
+		return fmt.Errorf("số lượng tham số truyền vào (%d) không khớp với số lượng điều kiện (%d)", len(params), n)
+	}
+	q := r.query(ctx).Where(strings.Join(qp.WhereClauses, " OR "), params...)
+	if qp.OrderBy != "" {
+		q = q.Order(qp.OrderBy)
+	}
+	if qp.Limit > 0 {
+		q = q.Limit(qp.Limit)
+	}
+	if first {
+		return q.First(dest).Error
+	}
+	return q.Find(dest).Error
+}
+
+func BenchmarkFinderAll(b *testing.B) {
+	const method = "FindAllByStatusAndPartnerIdOrTotalGreaterThanOrderByTotalDescLimit20"
+	r := newDryRunRepository(b)
+	ctx := context.Background()
+	f, err := r.Finder(method, "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var repo struct {
+		FindAllByStatusAndPartnerIdOrTotalGreaterThanOrderByTotalDescLimit20 func(ctx context.Context, status, partnerId string, total int) ([]proxyUser, error) `repo:"@Query"`
+	}
+	if err := r.FillFuncFields(&repo); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("baseline", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var res []proxyUser
+			if err := baselineFind(r, ctx, method, &res, false, "active", "p1", 10); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("finder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := f.All(ctx, 0, "active", "p1", 10); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("fillfuncfields", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.FindAllByStatusAndPartnerIdOrTotalGreaterThanOrderByTotalDescLimit20(ctx, "active", "p1", 10); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkFinderOne(b *testing.B) {
+	const method = "FindFirstByStatusAndPartnerIdOrderByTotalDesc"
+	r := newDryRunRepository(b)
+	ctx := context.Background()
+	f, err := r.Finder(method, "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var repo struct {
+		FindFirstByStatusAndPartnerIdOrderByTotalDesc func(ctx context.Context, status, partnerId string) (*proxyUser, error) `repo:"@Query"`
+	}
+	if err := r.FillFuncFields(&repo); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("baseline", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := baselineFind(r, ctx, method, new(proxyUser), true, "active", "p1"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("finder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := f.One(ctx, "active", "p1"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("fillfuncfields", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.FindFirstByStatusAndPartnerIdOrderByTotalDesc(ctx, "active", "p1"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package repo
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// Các thao tác được ghi trong lịch sử thay đổi
+const (
+	OpInsert  = "insert"
+	OpUpdate  = "update"
+	OpDelete  = "delete"
+	OpRestore = "restore"
+)
+
+// HistoryRepository đọc lịch sử thay đổi ghi bởi WithHistory, *Repository[T, ID] cài đặt sẵn
+type HistoryRepository[T any, ID comparable] interface {
+	Revisions(ctx context.Context, id ID) ([]Revision, error)
+	AsOf(ctx context.Context, id ID, at time.Time) (*T, error)
+}
+
+var _ HistoryRepository[struct{}, int] = (*Repository[struct{}, int])(nil)
+
+// Revision một dòng lịch sử thay đổi của entity, tạo bảng bằng ds.AutoMigrate(&repo.Revision{})
+type Revision struct {
+	ID         uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	EntityType string    `gorm:"column:entity_type;size:128;index:idx_entity_revisions_entity" json:"entityType"`
+	EntityID   string    `gorm:"column:entity_id;size:191;index:idx_entity_revisions_entity" json:"entityId"`
+	Operation  string    `gorm:"column:operation;size:16" json:"operation"`
+	Actor      string    `gorm:"column:actor;size:191" json:"actor"`
+	CreatedAt  time.Time `gorm:"column:created_at;index" json:"createdAt"`
+	Changes    string    `gorm:"column:changes;type:text" json:"changes"` // JSON {"cột": {"old": ..., "new": ...}}
+}
+
+func (Revision) TableName() string {
+	return "entity_revisions"
+}
+
+// RevisionChange giá trị cũ/mới của một cột trong Revision.Changes
+type RevisionChange struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+// ChangeSet giải mã Revision.Changes thành map cột -> giá trị cũ/mới
+func (rev *Revision) ChangeSet() (map[string]RevisionChange, error) {
+	changes := map[string]RevisionChange{}
+	if err := json.Unmarshal([]byte(rev.Changes), &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// WithHistory ghi lịch sử thay đổi (Revision) cho Insert, Update, Patch, UpdateFields, Delete, Restore
+// và các thao tác hàng loạt (kể cả PurgeDeleted), trong cùng transaction với thao tác ghi
+func WithHistory() Option {
+	return func(o *options) {
+		o.history = true
+	}
+}
+
+// recordHistory chạy fn trong transaction bằng repository không ghi lịch sử, so sánh trạng thái các entity
+// có khóa chính ids trước và sau fn rồi ghi Revision cho các entity có thay đổi
+func (r *Repository[T, ID]) recordHistory(ctx context.Context, ids func() []any, fn func(ctx context.Context, r *Repository[T, ID]) error) error {
+	meta, err := r.entity()
+	if err != nil {
+		return err
+	}
+	plain := r.With(func(o *options) { o.history = false })
+	return r.Transactional(ctx, func(ctx context.Context) error {
+		before, err := r.historyState(ctx, meta, ids())
+		if err != nil {
+			return err
+		}
+		if err := fn(ctx, plain); err != nil {
+			return err
+		}
+		after, err := r.historyState(ctx, meta, ids())
+		if err != nil {
+			return err
+		}
+		return r.writeRevisions(ctx, meta, before, after)
+	})
+}
+
+// historyState đọc snapshot các entity theo khóa chính (kể cả đã xóa mềm), key là khóa chính dạng chuỗi
+func (r *Repository[T, ID]) historyState(ctx context.Context, meta *entityMeta, ids []any) (map[string]map[string]any, error) {
+	state := map[string]map[string]any{}
+	var keys []any
+	for _, id := range ids {
+		if id != nil && !reflect.ValueOf(id).IsZero() {
+			keys = append(keys, id)
+		}
+	}
+	for _, chunk := range chunkIDs(keys, r.idChunkSize()) {
+		cond, err := idIn(meta, chunk)
+		if err != nil {
+			return nil, err
+		}
+		var rows []T
+		if err := r.scoped(ctx, new(T), includeDeleted).Where(cond).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			rv := reflect.ValueOf(&rows[i]).Elem()
+			state[meta.idString(meta.primaryKey(ctx, rv))] = meta.snapshot(ctx, rv)
+		}
+	}
+	return state, nil
+}
+
+// writeRevisions ghi Revision cho mỗi entity có trạng thái trước/sau khác nhau
+func (r *Repository[T, ID]) writeRevisions(ctx context.Context, meta *entityMeta, before, after map[string]map[string]any) error {
+	keys := make(map[string]bool, len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	var actor string
+	if auditor, ok := r.CurrentAuditor(ctx); ok {
+		actor = fmt.Sprint(auditor)
+	}
+	now := r.DB.NowFunc()
+
+	// Thứ tự cố định: các Revision của cùng một lần gọi có chung CreatedAt, id tăng theo khóa chính
+	var revisions []Revision
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		old, cur := before[key], after[key]
+		changes := map[string]map[string]any{}
+		for _, field := range meta.schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			var o, n any
+			if old != nil {
+				o = old[field.DBName]
+			}
+			if cur != nil {
+				n = cur[field.DBName]
+			}
+			if (old == nil || cur == nil) || !reflect.DeepEqual(o, n) {
+				changes[field.DBName] = map[string]any{"old": o, "new": n}
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		revisions = append(revisions, Revision{
+			EntityType: meta.table,
+			EntityID:   key,
+			Operation:  meta.operation(old, cur),
+			Actor:      actor,
+			CreatedAt:  now,
+			Changes:    string(data),
+		})
+	}
+	if len(revisions) == 0 {
+		return nil
+	}
+	return r.Conn(ctx).Create(&revisions).Error
+}
+
+// operation suy ra thao tác từ trạng thái trước/sau, xóa mềm/khôi phục dựa vào cột xóa mềm
+func (m *entityMeta) operation(old, cur map[string]any) string {
+	switch {
+	case old == nil:
+		return OpInsert
+	case cur == nil:
+		return OpDelete
+	case m.softDelete != nil:
+		wasDeleted, isDeleted := !isNullValue(old[m.softDelete.field.DBName]), !isNullValue(cur[m.softDelete.field.DBName])
+		if !wasDeleted && isDeleted {
+			return OpDelete
+		}
+		if wasDeleted && !isDeleted {
+			return OpRestore
+		}
+	}
+	return OpUpdate
+}
+
+// isNullValue giá trị tương ứng NULL trong DB (nil, pointer nil hoặc driver.Valuer trả về nil)
+func isNullValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return true
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		return err == nil && value == nil
+	}
+	return false
+}
+
+// entityIDs khóa chính của entity hoặc slice entity, đọc lại mỗi lần gọi để lấy ID sinh sau khi insert
+func (r *Repository[T, ID]) entityIDs(ctx context.Context, value any) func() []any {
+	return func() []any {
+		meta, err := r.entity()
+		if err != nil {
+			return nil
+		}
+		rv := reflect.Indirect(reflect.ValueOf(value))
+		if rv.Kind() != reflect.Slice {
+			return []any{meta.primaryKey(ctx, rv)}
+		}
+		ids := make([]any, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			ids = append(ids, meta.primaryKey(ctx, reflect.Indirect(rv.Index(i))))
+		}
+		return ids
+	}
+}
+
+// idList chuyển danh sách ID thành hàm trả về []any cho recordHistory
+func idList[ID any](ids ...ID) func() []any {
+	return func() []any {
+		list := make([]any, len(ids))
+		for i, id := range ids {
+			list[i] = id
+		}
+		return list
+	}
+}
+
+// Revisions lịch sử thay đổi của entity theo thứ tự thời gian
+func (r *Repository[T, ID]) Revisions(ctx context.Context, id ID) ([]Revision, error) {
+	meta, err := r.entity()
+	if err != nil {
+		return nil, err
+	}
+	var revisions []Revision
+	err = r.Conn(ctx).Where(&Revision{EntityType: meta.table, EntityID: meta.idString(id)}).Order("id").Find(&revisions).Error
+	return revisions, err
+}
+
+// AsOf dựng lại entity tại thời điểm at: lấy trạng thái hiện tại rồi hoàn tác các Revision sau at.
+// Trả về ErrNotFound nếu tại thời điểm đó entity chưa được tạo hoặc đã bị xóa hẳn.
+func (r *Repository[T, ID]) AsOf(ctx context.Context, id ID, at time.Time) (*T, error) {
+	meta, err := r.entity()
+	if err != nil {
+		return nil, err
+	}
+	cond, err := meta.idEq(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var state map[string]json.RawMessage
+	current := new(T)
+	res := r.scoped(ctx, new(T), includeDeleted).Where(cond).Limit(1).Find(current)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		state = map[string]json.RawMessage{}
+		for column, value := range meta.snapshot(ctx, reflect.ValueOf(current).Elem()) {
+			if state[column], err = json.Marshal(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var revisions []Revision
+	err = r.Conn(ctx).Where(&Revision{EntityType: meta.table, EntityID: meta.idString(id)}).
+		Where(clause.Gt{Column: clause.Column{Name: "created_at"}, Value: at}).
+		Order("id DESC").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		if revisions[i].Operation == OpInsert {
+			state = nil
+			continue
+		}
+		changes, err := revisions[i].ChangeSet()
+		if err != nil {
+			return nil, err
+		}
+		if state == nil {
+			state = map[string]json.RawMessage{}
+		}
+		for column, change := range changes {
+			state[column] = change.Old
+		}
+	}
+	if state == nil {
+		return nil, ErrNotFound
+	}
+
+	entity := new(T)
+	rv := reflect.ValueOf(entity).Elem()
+	for _, field := range meta.schema.Fields {
+		raw, ok := state[field.DBName]
+		if field.DBName == "" || !ok {
+			continue
+		}
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return nil, fmt.Errorf("repo: không giải mã được cột %s của revision: %w", field.DBName, err)
+		}
+		if err := field.Set(ctx, rv, value.Elem().Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return entity, nil
+}
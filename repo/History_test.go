@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type historyItem struct {
+	ID        uint
+	Name      string
+	DeletedAt gorm.DeletedAt
+}
+
+func TestPurgeDeletedHistory(t *testing.T) {
+	ds := newTestDataSource(t, &historyItem{}, &Revision{})
+	r := NewRepository[historyItem, uint](ds, WithHistory())
+	ctx := context.Background()
+	items := []historyItem{{Name: "old"}, {Name: "recent"}, {Name: "live"}}
+	if err := r.InsertAll(ctx, items, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.DeleteAllByIDs(ctx, []uint{items[0].ID, items[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.Unscoped().Model(&historyItem{}).Where("id = ?", items[0].ID).
+		UpdateColumn("deleted_at", time.Now().Add(-48*time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := r.PurgeDeleted(ctx, 24*time.Hour)
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeDeleted = %d, %v", n, err)
+	}
+	var left int64
+	ds.Unscoped().Model(&historyItem{}).Count(&left)
+	if left != 2 {
+		t.Errorf("còn %d dòng, muốn 2", left)
+	}
+
+	revs, err := r.Revisions(ctx, items[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := make([]string, len(revs))
+	for i, rev := range revs {
+		ops[i] = rev.Operation
+	}
+	// insert, xóa mềm, xóa hẳn
+	if len(ops) != 3 || ops[0] != OpInsert || ops[1] != OpDelete || ops[2] != OpDelete {
+		t.Errorf("revisions của bản ghi bị purge = %v", ops)
+	}
+	if revs, _ := r.Revisions(ctx, items[1].ID); len(revs) != 2 {
+		t.Errorf("bản ghi chưa quá hạn có %d revision, muốn 2", len(revs))
+	}
+	if _, err := r.AsOf(ctx, items[0].ID, time.Now()); err != ErrNotFound {
+		t.Errorf("AsOf sau khi purge: lỗi = %v", err)
+	}
+}
+
+func TestRevisionOrder(t *testing.T) {
+	ds := newTestDataSource(t, &historyItem{}, &Revision{})
+	r := NewRepository[historyItem, uint](ds, WithHistory())
+	ctx := context.Background()
+	items := make([]historyItem, 12)
+	for i := range items {
+		items[i].Name = "x"
+	}
+	if err := r.InsertAll(ctx, items, 5); err != nil {
+		t.Fatal(err)
+	}
+	// Các Revision của một lần gọi có chung created_at, id tăng theo khóa chính (dạng chuỗi)
+	var revs []Revision
+	if err := ds.Order("id").Find(&revs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != len(items) {
+		t.Fatalf("%d revision, muốn %d", len(revs), len(items))
+	}
+	for i := 1; i < len(revs); i++ {
+		if revs[i-1].EntityID >= revs[i].EntityID || !revs[i].CreatedAt.Equal(revs[0].CreatedAt) {
+			t.Errorf("revision %d: %s sau %s", i, revs[i].EntityID, revs[i-1].EntityID)
+		}
+	}
+}
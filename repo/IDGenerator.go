@@ -0,0 +1,326 @@
+package repo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Các chiến lược sinh khóa chính khai báo bằng tag repo:"@GeneratedValue(...)"
+const (
+	GenerateUUID      = "uuid"      // UUID v4 ngẫu nhiên
+	GenerateUUIDv7    = "uuidv7"    // UUID v7, tăng dần theo thời gian
+	GenerateULID      = "ulid"      // ULID 26 ký tự, tăng dần theo thời gian
+	GenerateSnowflake = "snowflake" // int64 kiểu Snowflake: thời gian | node | sequence
+	GenerateSequence  = "sequence"  // sequence của DB (postgres, MariaDB): @GeneratedValue(sequence=tên_sequence)
+)
+
+// idGenerator chiến lược sinh khóa chính của entity
+type idGenerator struct {
+	field    *schema.Field
+	strategy string
+	sequence string
+}
+
+// parseGeneratedValue đọc tag @GeneratedValue(strategy) hoặc @GeneratedValue(sequence=name)
+func parseGeneratedValue(s *schema.Schema, field *schema.Field, tag string, dialector gorm.Dialector) (*idGenerator, error) {
+	arg, ok := strings.CutPrefix(tag, "@GeneratedValue(")
+	if arg, ok = strings.CutSuffix(arg, ")"); !ok {
+		return nil, fmt.Errorf("repo: tag %q của %s.%s không hợp lệ, dùng @GeneratedValue(strategy)", tag, s.Name, field.Name)
+	}
+	if !field.PrimaryKey {
+		return nil, fmt.Errorf("repo: @GeneratedValue chỉ dùng cho khóa chính, %s.%s không phải khóa chính", s.Name, field.Name)
+	}
+	g := &idGenerator{field: field, strategy: strings.TrimSpace(arg)}
+	if name, ok := strings.CutPrefix(g.strategy, GenerateSequence+"="); ok {
+		g.strategy, g.sequence = GenerateSequence, strings.TrimSpace(name)
+	}
+
+	kind := field.IndirectFieldType.Kind()
+	var valid bool
+	switch g.strategy {
+	case GenerateUUID, GenerateUUIDv7:
+		valid = kind == reflect.String || (kind == reflect.Array && field.IndirectFieldType.Len() == 16)
+	case GenerateULID:
+		valid = kind == reflect.String
+	case GenerateSnowflake:
+		valid = kind == reflect.Int64 || kind == reflect.Uint64
+	case GenerateSequence:
+		if g.sequence == "" {
+			return nil, fmt.Errorf("repo: %s.%s thiếu tên sequence, dùng @GeneratedValue(sequence=name)", s.Name, field.Name)
+		}
+		switch dialect := dialector.Name(); {
+		case dialect == "mysql" && !mariaDB(dialector):
+			return nil, fmt.Errorf("repo: sequence chỉ hỗ trợ MariaDB, không hỗ trợ MySQL (%s.%s)", s.Name, field.Name)
+		case dialect != "postgres" && dialect != "mysql":
+			return nil, fmt.Errorf("repo: sequence không hỗ trợ trên %s (%s.%s)", dialect, s.Name, field.Name)
+		}
+		valid = isIntegerKind(kind)
+	default:
+		return nil, fmt.Errorf("repo: chiến lược sinh ID %q không hợp lệ (%s.%s)", g.strategy, s.Name, field.Name)
+	}
+	if !valid {
+		return nil, fmt.Errorf("repo: kiểu %s của %s.%s không dùng được với @GeneratedValue(%s)", field.FieldType, s.Name, field.Name, g.strategy)
+	}
+	return g, nil
+}
+
+// mariaDB dialector mysql kết nối tới MariaDB (có sequence và NEXTVAL), không biết phiên bản server
+// (SkipInitializeWithVersion) thì coi như MariaDB, lỗi sẽ xuất hiện ở lần Insert đầu tiên
+func mariaDB(dialector gorm.Dialector) bool {
+	d, ok := dialector.(*mysql.Dialector)
+	if !ok || d.Config == nil || d.ServerVersion == "" {
+		return true
+	}
+	return strings.Contains(d.ServerVersion, "MariaDB")
+}
+
+// SnowflakeNode node id (0-1023) dùng khi sinh ID Snowflake, mỗi instance ứng dụng cần một node khác nhau
+func SnowflakeNode(node int64) Option {
+	return func(o *options) {
+		o.snowflakeNode = node
+	}
+}
+
+// pendingIDs các entity trong value (entity hoặc slice entity) có khóa chính zero, sẽ được sinh ID khi insert
+func (m *entityMeta) pendingIDs(ctx context.Context, value any) []reflect.Value {
+	g := m.generator
+	if g == nil {
+		return nil
+	}
+	var pending []reflect.Value
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if rv.Kind() != reflect.Slice {
+		if _, isZero := g.field.ValueOf(ctx, rv); isZero {
+			pending = append(pending, rv)
+		}
+		return pending
+	}
+	for i := 0; i < rv.Len(); i++ {
+		row := reflect.Indirect(rv.Index(i))
+		if _, isZero := g.field.ValueOf(ctx, row); isZero {
+			pending = append(pending, row)
+		}
+	}
+	return pending
+}
+
+// withGeneratedIDs chạy create (Insert, InsertAll, Upsert, UpsertAll), khi lỗi thì đặt lại khóa chính zero
+// cho các entity được sinh ID để entity không giữ ID chưa từng được lưu
+func (r *Repository[T, ID]) withGeneratedIDs(ctx context.Context, value any, create func() error) error {
+	meta, err := r.entity()
+	if err != nil {
+		return err
+	}
+	pending := meta.pendingIDs(ctx, value)
+	if err := create(); err != nil {
+		for _, row := range pending {
+			_ = meta.generator.field.Set(ctx, row, reflect.Zero(meta.generator.field.FieldType).Interface())
+		}
+		return err
+	}
+	return nil
+}
+
+// generateIDs gán khóa chính cho entity/slice entity có khóa chính đang zero, ID người gọi đã gán được giữ nguyên
+func (r *Repository[T, ID]) generateIDs(ctx context.Context, meta *entityMeta, value any) error {
+	g := meta.generator
+	pending := meta.pendingIDs(ctx, value)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var sequence []int64
+	if g.strategy == GenerateSequence {
+		var err error
+		if sequence, err = r.nextSequence(ctx, g.sequence, len(pending)); err != nil {
+			return err
+		}
+	}
+	for i, row := range pending {
+		var id any
+		switch g.strategy {
+		case GenerateUUID, GenerateUUIDv7:
+			newUUID := uuid.NewRandom
+			if g.strategy == GenerateUUIDv7 {
+				newUUID = uuid.NewV7
+			}
+			u, err := newUUID()
+			if err != nil {
+				return err
+			}
+			if g.field.IndirectFieldType.Kind() == reflect.String {
+				id = u.String()
+			} else {
+				id = reflect.ValueOf(u).Convert(g.field.IndirectFieldType).Interface()
+			}
+		case GenerateULID:
+			u, err := newULID()
+			if err != nil {
+				return err
+			}
+			id = u
+		case GenerateSnowflake:
+			gen, err := snowflakeFor(r.opts.snowflakeNode)
+			if err != nil {
+				return err
+			}
+			id = gen.next()
+		case GenerateSequence:
+			id = sequence[i]
+		}
+		if err := g.field.Set(ctx, row, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextSequence lấy n giá trị tiếp theo của sequence (postgres nextval, MariaDB NEXTVAL)
+func (r *Repository[T, ID]) nextSequence(ctx context.Context, name string, n int) ([]int64, error) {
+	q := r.Conn(ctx)
+	values := make([]int64, 0, n)
+	switch q.Dialector.Name() {
+	case "postgres":
+		err := q.Raw("SELECT nextval(?::regclass) FROM generate_series(1, ?)", name, n).Scan(&values).Error
+		return values, err
+	case "mysql":
+		query := "SELECT NEXTVAL(" + q.Statement.Quote(name) + ")"
+		for i := 0; i < n; i++ {
+			var v int64
+			if err := q.Raw(query).Scan(&v).Error; err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("repo: sequence không hỗ trợ trên %s", q.Dialector.Name())
+}
+
+// prepareCreate gán khóa chính sinh tự động và các field audit trước khi insert
+func (r *Repository[T, ID]) prepareCreate(ctx context.Context, meta *entityMeta, value any) error {
+	if err := r.generateIDs(ctx, meta, value); err != nil {
+		return err
+	}
+	r.auditCreate(ctx, meta, value)
+	return nil
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidState ULID sinh gần nhất, để các ULID trong cùng mili giây vẫn tăng dần
+var ulidState struct {
+	sync.Mutex
+	ms   uint64
+	last [16]byte
+}
+
+// newULID sinh ULID: 48 bit thời gian (ms) + 80 bit ngẫu nhiên, mã hóa Crockford base32.
+// Trong cùng mili giây (hoặc khi đồng hồ bị lùi) phần ngẫu nhiên của ULID trước được tăng thêm 1 nên kết quả luôn tăng dần.
+func newULID() (string, error) {
+	ms := uint64(time.Now().UnixMilli())
+	ulidState.Lock()
+	defer ulidState.Unlock()
+	b := &ulidState.last
+	if ms <= ulidState.ms {
+		if !increment(b[6:]) {
+			return "", fmt.Errorf("repo: hết ULID trong mili giây %d", ulidState.ms)
+		}
+		return encodeULID(b), nil
+	}
+	ulidState.ms = ms
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	return encodeULID(b), nil
+}
+
+// increment tăng số big-endian b thêm 1, false khi tràn
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID mã hóa 128 bit thành 26 ký tự Crockford base32 (5 bit mỗi ký tự, ký tự đầu chỉ dùng 3 bit)
+func encodeULID(b *[16]byte) string {
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := range out {
+		shift := uint(125 - 5*i)
+		var v uint64
+		switch {
+		case shift >= 64:
+			v = hi >> (shift - 64)
+		case shift+5 <= 64:
+			v = lo >> shift
+		default:
+			v = lo>>shift | hi<<(64-shift)
+		}
+		out[i] = crockford[v&31]
+	}
+	return string(out[:])
+}
+
+const (
+	snowflakeEpoch    = 1577836800000 // 2020-01-01 UTC (ms)
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+	snowflakeMaxNode  = 1<<snowflakeNodeBits - 1
+	snowflakeSeqMask  = 1<<snowflakeSeqBits - 1
+)
+
+// snowflake sinh ID int64 tăng dần: 41 bit thời gian | 10 bit node | 12 bit sequence trong cùng ms
+type snowflake struct {
+	mu   sync.Mutex
+	node int64
+	last int64
+	seq  int64
+}
+
+var snowflakes sync.Map // node -> *snowflake, dùng chung giữa các repository
+
+func snowflakeFor(node int64) (*snowflake, error) {
+	if node < 0 || node > snowflakeMaxNode {
+		return nil, fmt.Errorf("repo: snowflake node %d ngoài khoảng 0-%d", node, snowflakeMaxNode)
+	}
+	gen, _ := snowflakes.LoadOrStore(node, &snowflake{node: node})
+	return gen.(*snowflake), nil
+}
+
+func (s *snowflake) next() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UnixMilli() - snowflakeEpoch
+	if now < s.last {
+		now = s.last // đồng hồ bị lùi: tiếp tục trên mốc cũ
+	}
+	if now == s.last {
+		s.seq = (s.seq + 1) & snowflakeSeqMask
+		for s.seq == 0 && now <= s.last {
+			time.Sleep(100 * time.Microsecond)
+			now = time.Now().UnixMilli() - snowflakeEpoch
+		}
+	} else {
+		s.seq = 0
+	}
+	s.last = now
+	return now<<(snowflakeNodeBits+snowflakeSeqBits) | s.node<<snowflakeSeqBits | s.seq
+}
@@ -0,0 +1,210 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestEncodeULID(t *testing.T) {
+	var zero, max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	// Ví dụ trong đặc tả ULID: 01ARYZ6S41 là thời điểm 1469918176385 ms
+	var spec [16]byte
+	ms := uint64(1469918176385)
+	for i := 0; i < 6; i++ {
+		spec[i] = byte(ms >> (40 - 8*i))
+	}
+	tests := []struct {
+		b    [16]byte
+		want string
+	}{
+		{zero, "00000000000000000000000000"},
+		{max, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"},
+		{spec, "01ARYZ6S410000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := encodeULID(&tt.b); got != tt.want {
+			t.Errorf("encodeULID(%x) = %s, muốn %s", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNewULIDMonotonic(t *testing.T) {
+	start := time.Now().UnixMilli()
+	prev := ""
+	for i := 0; i < 10000; i++ {
+		id, err := newULID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) != 26 || strings.Trim(id, crockford) != "" {
+			t.Fatalf("ULID %q không phải 26 ký tự Crockford base32", id)
+		}
+		if id <= prev {
+			t.Fatalf("ULID không tăng dần: %s sau %s", id, prev)
+		}
+		prev = id
+	}
+	// 10 ký tự đầu là thời gian (ms)
+	var ms int64
+	for _, c := range prev[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockford, c))
+	}
+	if ms < start || ms > time.Now().UnixMilli() {
+		t.Errorf("thời gian trong ULID %d ngoài khoảng [%d, now]", ms, start)
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	b := []byte{0x00, 0xff, 0xff}
+	if !increment(b) || b[0] != 1 || b[1] != 0 || b[2] != 0 {
+		t.Errorf("increment = %x", b)
+	}
+	b = []byte{0xff, 0xff}
+	if increment(b) {
+		t.Error("increment phải báo tràn")
+	}
+}
+
+func TestSnowflakeLayout(t *testing.T) {
+	gen := &snowflake{node: 693}
+	before := time.Now().UnixMilli() - snowflakeEpoch
+	var prev int64
+	for i := 0; i < 5000; i++ {
+		id := gen.next()
+		if id <= prev {
+			t.Fatalf("snowflake không tăng dần: %d sau %d", id, prev)
+		}
+		prev = id
+		if node := id >> snowflakeSeqBits & snowflakeMaxNode; node != 693 {
+			t.Fatalf("node = %d, muốn 693", node)
+		}
+	}
+	after := time.Now().UnixMilli() - snowflakeEpoch
+	if ts := prev >> (snowflakeNodeBits + snowflakeSeqBits); ts < before || ts > after {
+		t.Errorf("thời gian %d ngoài khoảng [%d, %d]", ts, before, after)
+	}
+	if prev < 0 {
+		t.Error("snowflake phải dương (bit dấu bằng 0)")
+	}
+
+	// Đồng hồ bị lùi: tiếp tục trên mốc cũ, chỉ tăng sequence
+	gen.last += 5
+	last := gen.last
+	id := gen.next()
+	if ts, seq := id>>(snowflakeNodeBits+snowflakeSeqBits), id&snowflakeSeqMask; ts != last || seq != gen.seq || id <= prev {
+		t.Errorf("đồng hồ lùi: thời gian %d (muốn %d), sequence %d", ts, last, seq)
+	}
+}
+
+func TestSnowflakeFor(t *testing.T) {
+	for _, node := range []int64{-1, 1024} {
+		if _, err := snowflakeFor(node); err == nil {
+			t.Errorf("node %d phải lỗi", node)
+		}
+	}
+	a, err := snowflakeFor(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := snowflakeFor(7); a != b {
+		t.Error("cùng node phải dùng chung bộ sinh")
+	}
+}
+
+type sequenceEntity struct {
+	ID   int64 `gorm:"primaryKey" repo:"@GeneratedValue(sequence=order_seq)"`
+	Name string
+}
+
+func TestParseGeneratedValueSequence(t *testing.T) {
+	s, err := schema.Parse(&sequenceEntity{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := s.PrioritizedPrimaryField
+	tests := []struct {
+		name      string
+		dialector gorm.Dialector
+		err       string
+	}{
+		{"postgres", postgres.Open("host=localhost"), ""},
+		{"mariadb", &mysql.Dialector{Config: &mysql.Config{ServerVersion: "10.11.6-MariaDB"}}, ""},
+		{"mysql", &mysql.Dialector{Config: &mysql.Config{ServerVersion: "8.0.36"}}, "chỉ hỗ trợ MariaDB"},
+		{"mysql chưa rõ phiên bản", &mysql.Dialector{Config: &mysql.Config{}}, ""},
+		{"sqlite", newTestDataSource(t).Dialector, "không hỗ trợ trên sqlite"},
+	}
+	for _, tt := range tests {
+		g, err := parseGeneratedValue(s, field, field.Tag.Get("repo"), tt.dialector)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err == "" && (g.strategy != GenerateSequence || g.sequence != "order_seq"):
+			t.Errorf("%s: %+v", tt.name, g)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: lỗi = %v, muốn chứa %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+type ulidEntity struct {
+	ID   string `gorm:"primaryKey;size:26" repo:"@GeneratedValue(ulid)"`
+	Code string `gorm:"uniqueIndex"`
+}
+
+func TestGeneratedIDsResetOnError(t *testing.T) {
+	r := NewRepository[ulidEntity, string](newTestDataSource(t, &ulidEntity{}))
+	ctx := context.Background()
+	if err := r.Insert(ctx, &ulidEntity{Code: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Insert lỗi: ID sinh ra được xóa, lần thử lại sinh ID mới
+	dup := &ulidEntity{Code: "a"}
+	if err := r.Insert(ctx, dup); err == nil {
+		t.Fatal("Insert trùng Code không lỗi")
+	}
+	if dup.ID != "" {
+		t.Errorf("Insert lỗi: ID = %q, muốn rỗng", dup.ID)
+	}
+	dup.Code = "b"
+	if err := r.Insert(ctx, dup); err != nil || dup.ID == "" {
+		t.Errorf("thử lại: ID = %q, %v", dup.ID, err)
+	}
+
+	// InsertAll lỗi: chỉ xóa ID đã sinh, ID người gọi gán được giữ nguyên
+	const assigned = "01ARZ3NDEKTSV4RRFFQ69G5FAV"
+	rows := []ulidEntity{{Code: "c"}, {ID: assigned, Code: "d"}, {Code: "a"}}
+	if err := r.InsertAll(ctx, rows, 10); err == nil {
+		t.Fatal("InsertAll trùng Code không lỗi")
+	}
+	if rows[0].ID != "" || rows[1].ID != assigned || rows[2].ID != "" {
+		t.Errorf("InsertAll lỗi: ID = %q %q %q", rows[0].ID, rows[1].ID, rows[2].ID)
+	}
+
+	// Upsert xung đột theo khóa chính nhưng trùng Code: lỗi, ID sinh ra được xóa
+	up := &ulidEntity{Code: "a"}
+	if err := r.Upsert(ctx, up, OnConflict("id"), UpdateColumns("code")); err == nil {
+		t.Fatal("Upsert trùng Code không lỗi")
+	}
+	if up.ID != "" {
+		t.Errorf("Upsert lỗi: ID = %q, muốn rỗng", up.ID)
+	}
+	ups := []ulidEntity{{Code: "f"}, {Code: "a"}}
+	if err := r.UpsertAll(ctx, ups, 10, OnConflict("id"), UpdateColumns("code")); err == nil {
+		t.Fatal("UpsertAll trùng Code không lỗi")
+	}
+	if ups[0].ID != "" || ups[1].ID != "" {
+		t.Errorf("UpsertAll lỗi: ID = %q %q", ups[0].ID, ups[1].ID)
+	}
+}
@@ -0,0 +1,429 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Lý do đề xuất index
+const (
+	IndexForFilter = "filter" // điều kiện WHERE không dùng được index nào
+	IndexForSort   = "sort"   // OrderBy phải sắp xếp toàn bộ kết quả (filesort)
+)
+
+// IndexAdvice một hàm dynamic lọc hoặc sắp xếp trên cột không có index dùng được
+type IndexAdvice struct {
+	Method     string   `json:"method"`
+	Table      string   `json:"table"`
+	Columns    []string `json:"columns"` // cột nên đánh index, theo thứ tự: điều kiện bằng, rồi sắp xếp hoặc khoảng
+	Reasons    []string `json:"reasons"` // IndexForFilter, IndexForSort
+	Suggestion string   `json:"suggestion"`
+}
+
+func (a IndexAdvice) String() string {
+	return fmt.Sprintf("%s: %s(%s) thiếu index cho %s, gợi ý: %s",
+		a.Method, a.Table, strings.Join(a.Columns, ", "), strings.Join(a.Reasons, ", "), a.Suggestion)
+}
+
+// ErrMissingIndex có hàm dynamic lọc/sắp xếp trên cột không có index, xem CheckIndexes với FailOnMissingIndex
+var ErrMissingIndex = errors.New("repo: thiếu index cho hàm dynamic")
+
+// MissingIndexError danh sách đề xuất index, errors.Is(err, ErrMissingIndex) trả về true
+type MissingIndexError struct {
+	Advices []IndexAdvice
+}
+
+func (e *MissingIndexError) Error() string {
+	lines := make([]string, len(e.Advices))
+	for i, a := range e.Advices {
+		lines[i] = a.String()
+	}
+	return fmt.Sprintf("%v:\n  %s", ErrMissingIndex, strings.Join(lines, "\n  "))
+}
+
+func (e *MissingIndexError) Is(target error) bool {
+	return target == ErrMissingIndex
+}
+
+// IndexCheckOption tùy chọn của CheckIndexes
+type IndexCheckOption func(o *indexCheckOptions)
+
+type indexCheckOptions struct {
+	fail    bool
+	ignored []string
+}
+
+// FailOnMissingIndex CheckIndexes trả về *MissingIndexError thay vì chỉ ghi cảnh báo vào logger của gorm
+func FailOnMissingIndex() IndexCheckOption {
+	return func(o *indexCheckOptions) {
+		o.fail = true
+	}
+}
+
+// IgnoreMethods bỏ qua các hàm dynamic này khi kiểm tra (bảng nhỏ, hàm chỉ dùng cho batch, ...)
+func IgnoreMethods(methods ...string) IndexCheckOption {
+	return func(o *indexCheckOptions) {
+		o.ignored = append(o.ignored, methods...)
+	}
+}
+
+// CheckIndexes đối chiếu điều kiện lọc và sắp xếp của các hàm dynamic đã wiring (FillFuncFields, Finder, repogen)
+// với index thật của database (pg_indexes, information_schema.statistics, PRAGMA index_list của sqlite).
+// Gọi một lần khi khởi động sau khi wiring, ví dụ ở staging với FailOnMissingIndex; mặc định mỗi đề xuất
+// được ghi cảnh báo vào logger của gorm. Index dùng được khi cột đầu của index là một cột điều kiện,
+// với OrderBy khi các cột đứng trước cột sắp xếp trong index đều là điều kiện bằng.
+func (r *Repository[T, ID]) CheckIndexes(ctx context.Context, opts ...IndexCheckOption) ([]IndexAdvice, error) {
+	var o indexCheckOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if r.DataSource == nil || r.DB == nil {
+		return nil, fmt.Errorf("repo: CheckIndexes cần kết nối database")
+	}
+	conn := r.Conn(ctx)
+	cache := map[string][][]string{}
+	indexes := func(table string) ([][]string, error) {
+		if idx, ok := cache[table]; ok {
+			return idx, nil
+		}
+		idx, err := tableIndexes(conn, table)
+		if err != nil {
+			return nil, fmt.Errorf("repo: đọc index của %s: %w", table, err)
+		}
+		cache[table] = idx
+		return idx, nil
+	}
+
+	var advices []IndexAdvice
+	for _, f := range r.finders.all() {
+		if slices.Contains(o.ignored, f.name) {
+			continue
+		}
+		advice, err := f.adviseIndexes(indexes)
+		if err != nil {
+			return nil, err
+		}
+		advices = append(advices, advice...)
+	}
+	if o.fail && len(advices) > 0 {
+		return advices, &MissingIndexError{Advices: advices}
+	}
+	for _, a := range advices {
+		conn.Logger.Warn(ctx, "repo: %s", a)
+	}
+	return advices, nil
+}
+
+// indexNeed các cột của một bảng mà một nhóm điều kiện AND (và OrderBy) cần tới
+type indexNeed struct {
+	table string
+	eq    []string // =, IN, IS NULL
+	rng   []string // >, <, BETWEEN, LIKE
+	sort  string
+}
+
+// adviseIndexes đề xuất index cho từng nhóm điều kiện của hàm, indexes trả về các index (danh sách cột) của một bảng
+func (f *Finder[T, ID]) adviseIndexes(indexes func(table string) ([][]string, error)) ([]IndexAdvice, error) {
+	var groups [][]whereCondition
+	var orderBy string
+	if f.aggregate != nil {
+		groups = f.aggregate.conditions
+	} else {
+		groups = f.qp.conditions
+		if f.qp.OrderBy != "" {
+			orderBy = f.qp.OrderBy[:strings.LastIndex(f.qp.OrderBy, " ")]
+		}
+	}
+	if len(groups) == 0 {
+		groups = [][]whereCondition{nil} // chỉ có OrderBy, ví dụ FindFirstByOrderByCreatedAtDesc
+	}
+
+	var advices []IndexAdvice
+	for _, group := range groups {
+		var needs []*indexNeed
+		need := func(table string) *indexNeed {
+			for _, n := range needs {
+				if n.table == table {
+					return n
+				}
+			}
+			n := &indexNeed{table: table}
+			needs = append(needs, n)
+			return n
+		}
+		for _, cond := range group {
+			src, ok := f.sources[cond.column]
+			if !ok {
+				continue
+			}
+			switch n := need(src.table); cond.op {
+			case "=", "IN", "IS NULL":
+				if !slices.Contains(n.eq, src.column) {
+					n.eq = append(n.eq, src.column)
+				}
+			case ">", ">=", "<", "<=", "BETWEEN", "LIKE":
+				if !slices.Contains(n.rng, src.column) {
+					n.rng = append(n.rng, src.column)
+				}
+			}
+		}
+		// Nhiều nhóm OR thì kết quả phải gộp lại trước khi sắp xếp, index không giúp được OrderBy
+		if src, ok := f.sources[orderBy]; ok && len(groups) == 1 {
+			need(src.table).sort = src.column
+		}
+
+		for _, n := range needs {
+			idx, err := indexes(n.table)
+			if err != nil {
+				return nil, err
+			}
+			var reasons []string
+			if !n.filterIndexed(idx) {
+				reasons = append(reasons, IndexForFilter)
+			}
+			if !n.sortIndexed(idx) {
+				reasons = append(reasons, IndexForSort)
+			}
+			if len(reasons) == 0 {
+				continue
+			}
+			columns := slices.Clone(n.eq)
+			switch {
+			case n.sort != "" && !slices.Contains(n.eq, n.sort):
+				columns = append(columns, n.sort)
+			case len(n.rng) > 0:
+				columns = append(columns, n.rng[0])
+			}
+			advice := IndexAdvice{Method: f.name, Table: n.table, Columns: columns, Reasons: reasons, Suggestion: f.createIndex(n.table, columns)}
+			if !slices.ContainsFunc(advices, func(a IndexAdvice) bool {
+				return a.Table == advice.Table && slices.Equal(a.Columns, advice.Columns)
+			}) {
+				advices = append(advices, advice)
+			}
+		}
+	}
+	return advices, nil
+}
+
+// filterIndexed có index bắt đầu bằng một cột điều kiện (không có điều kiện dùng được index thì bỏ qua)
+func (n *indexNeed) filterIndexed(indexes [][]string) bool {
+	if len(n.eq)+len(n.rng) == 0 {
+		return true
+	}
+	for _, idx := range indexes {
+		if len(idx) > 0 && (slices.Contains(n.eq, idx[0]) || slices.Contains(n.rng, idx[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortIndexed có index chứa cột sắp xếp mà các cột đứng trước đều là điều kiện bằng
+func (n *indexNeed) sortIndexed(indexes [][]string) bool {
+	if n.sort == "" || slices.Contains(n.eq, n.sort) {
+		return true
+	}
+	for _, idx := range indexes {
+		for _, column := range idx {
+			if column == n.sort {
+				return true
+			}
+			if !slices.Contains(n.eq, column) {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// createIndex câu CREATE INDEX gợi ý, tên index theo NamingStrategy của gorm
+func (f *Finder[T, ID]) createIndex(table string, columns []string) string {
+	stmt := f.repo.DB.Statement
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = stmt.Quote(column)
+	}
+	name := f.repo.DB.NamingStrategy.IndexName(table, strings.Join(columns, "_"))
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", stmt.Quote(name), stmt.Quote(table), strings.Join(quoted, ", "))
+}
+
+// tableIndexes các index của table (kể cả khóa chính), mỗi index là danh sách cột theo thứ tự;
+// cột biểu thức (lower(email), ...) là chuỗi rỗng. table có thể kèm schema: sales.orders
+func tableIndexes(conn *gorm.DB, table string) ([][]string, error) {
+	schemaName, name := "", table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schemaName, name = table[:i], table[i+1:]
+	}
+	var rows []map[string]any
+	var indexes [][]string
+	switch dialect := conn.Dialector.Name(); dialect {
+	case "postgres":
+		query := "SELECT indexname, indexdef FROM pg_indexes WHERE schemaname = current_schema() AND tablename = ?"
+		args := []any{name}
+		if schemaName != "" {
+			query = "SELECT indexname, indexdef FROM pg_indexes WHERE schemaname = ? AND tablename = ?"
+			args = []any{schemaName, name}
+		}
+		if err := conn.Raw(query, args...).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			indexes = append(indexes, postgresIndexColumns(stringValue(row["indexdef"])))
+		}
+	case "mysql":
+		query := "SELECT INDEX_NAME AS index_name, COLUMN_NAME AS column_name FROM information_schema.statistics " +
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX"
+		args := []any{name}
+		if schemaName != "" {
+			query = strings.Replace(query, "DATABASE()", "?", 1)
+			args = []any{schemaName, name}
+		}
+		if err := conn.Raw(query, args...).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		byName := map[string]int{}
+		for _, row := range rows {
+			index := stringValue(row["index_name"])
+			i, ok := byName[index]
+			if !ok {
+				i = len(indexes)
+				byName[index] = i
+				indexes = append(indexes, nil)
+			}
+			indexes[i] = append(indexes[i], stringValue(row["column_name"]))
+		}
+	case "sqlite":
+		// Khóa chính INTEGER PRIMARY KEY là rowid, không nằm trong index_list
+		var pk []map[string]any
+		if err := conn.Raw("PRAGMA table_info(" + conn.Statement.Quote(table) + ")").Scan(&pk).Error; err != nil {
+			return nil, err
+		}
+		sort.SliceStable(pk, func(i, j int) bool { return intValue(pk[i]["pk"]) < intValue(pk[j]["pk"]) })
+		var primary []string
+		for _, column := range pk {
+			if intValue(column["pk"]) > 0 {
+				primary = append(primary, stringValue(column["name"]))
+			}
+		}
+		if len(primary) > 0 {
+			indexes = append(indexes, primary)
+		}
+		if err := conn.Raw("PRAGMA index_list(" + conn.Statement.Quote(table) + ")").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			var info []map[string]any
+			if err := conn.Raw("PRAGMA index_info(" + conn.Statement.Quote(stringValue(row["name"])) + ")").Scan(&info).Error; err != nil {
+				return nil, err
+			}
+			sort.SliceStable(info, func(i, j int) bool { return intValue(info[i]["seqno"]) < intValue(info[j]["seqno"]) })
+			columns := make([]string, len(info))
+			for i, column := range info {
+				columns[i] = stringValue(column["name"])
+			}
+			indexes = append(indexes, columns)
+		}
+	default:
+		return nil, fmt.Errorf("kiểm tra index chưa hỗ trợ %s", dialect)
+	}
+	return indexes, nil
+}
+
+// postgresIndexColumns các cột trong indexdef của pg_indexes:
+// CREATE UNIQUE INDEX users_pkey ON public.users USING btree (id, "createdAt" DESC) INCLUDE (...) WHERE ...
+func postgresIndexColumns(def string) []string {
+	using := strings.Index(def, " USING ")
+	if using < 0 {
+		return nil
+	}
+	def = def[using:]
+	start := strings.Index(def, "(")
+	if start < 0 {
+		return nil
+	}
+	var columns []string
+	var quote byte // tên trong "..." hoặc chuỗi '...' của biểu thức, dấu nháy nhân đôi là đóng rồi mở lại
+	depth, from := 0, start+1
+	for i := start; i < len(def); i++ {
+		switch c := def[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' || (c == ',' && depth == 1):
+			if c == ')' {
+				depth--
+				if depth > 0 {
+					continue
+				}
+			}
+			columns = append(columns, postgresIndexColumn(strings.TrimSpace(def[from:i])))
+			from = i + 1
+			if depth == 0 {
+				return columns
+			}
+		}
+	}
+	return columns
+}
+
+// postgresIndexColumn tên cột của một phần tử index ("createdAt" DESC -> createdAt), biểu thức trả về ""
+func postgresIndexColumn(item string) string {
+	if quoted, ok := strings.CutPrefix(item, `"`); ok {
+		var b strings.Builder
+		for i := 0; i < len(quoted); i++ {
+			if quoted[i] == '"' {
+				if i+1 < len(quoted) && quoted[i+1] == '"' {
+					i++
+				} else {
+					return b.String()
+				}
+			}
+			b.WriteByte(quoted[i])
+		}
+	}
+	column, _, _ := strings.Cut(item, " ")
+	if strings.ContainsAny(column, "()") {
+		return ""
+	}
+	return column
+}
+
+func stringValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func intValue(v any) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case []byte:
+		var n int64
+		_, _ = fmt.Sscan(string(v), &n)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Các indexdef lấy từ pg_indexes của postgres 16
+func TestPostgresIndexColumns(t *testing.T) {
+	tests := []struct {
+		def  string
+		want []string
+	}{
+		{def: "CREATE UNIQUE INDEX proxy_users_pkey ON public.proxy_users USING btree (id)",
+			want: []string{"id"}},
+		{def: "CREATE INDEX idx_orders_partner_created ON public.orders USING btree (partner_id, created_at DESC)",
+			want: []string{"partner_id", "created_at"}},
+		{def: `CREATE INDEX "idx_orders_createdAt" ON sales.orders USING btree ("createdAt" DESC NULLS LAST, "Status")`,
+			want: []string{"createdAt", "Status"}},
+		{def: `CREATE INDEX idx_weird ON public.t USING btree ("a""b", "c,d")`,
+			want: []string{`a"b`, "c,d"}},
+		{def: "CREATE INDEX idx_orders_status_cover ON public.orders USING btree (status) INCLUDE (total, partner_id)",
+			want: []string{"status"}},
+		{def: "CREATE INDEX idx_orders_active ON public.orders USING btree (partner_id, status) WHERE (deleted_at IS NULL)",
+			want: []string{"partner_id", "status"}},
+		{def: "CREATE UNIQUE INDEX idx_users_lower_email ON public.users USING btree (lower((email)::text))",
+			want: []string{""}},
+		{def: "CREATE INDEX idx_orders_day ON public.orders USING btree (((created_at)::date), status)",
+			want: []string{"", "status"}},
+		{def: "CREATE INDEX idx_orders_label ON public.orders USING btree (COALESCE(label, ')'::text), id)",
+			want: []string{"", "id"}},
+		{def: "CREATE INDEX idx_users_name ON public.users USING btree (name COLLATE \"C\" text_pattern_ops)",
+			want: []string{"name"}},
+		{def: "CREATE INDEX idx_users_tags ON public.users USING gin (tags)",
+			want: []string{"tags"}},
+		{def: "CREATE INDEX orders_2024_partner_id_idx ON ONLY public.orders_2024 USING btree (partner_id)",
+			want: []string{"partner_id"}},
+		{def: "không phải indexdef", want: nil},
+	}
+	for _, tt := range tests {
+		if got := postgresIndexColumns(tt.def); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s\n got %q, muốn %q", tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestIndexNeedMatching(t *testing.T) {
+	defs := func(defs ...string) [][]string {
+		indexes := make([][]string, len(defs))
+		for i, def := range defs {
+			indexes[i] = postgresIndexColumns(def)
+		}
+		return indexes
+	}
+	tests := []struct {
+		name           string
+		need           indexNeed
+		indexes        [][]string
+		filter, sorted bool
+	}{
+		{name: "cột đầu là điều kiện bằng",
+			need:    indexNeed{eq: []string{"status"}},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (status, partner_id)"), filter: true, sorted: true},
+		{name: "cột điều kiện không đứng đầu",
+			need:    indexNeed{eq: []string{"status"}},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (partner_id, status)"), sorted: true},
+		{name: "cột đầu là điều kiện khoảng",
+			need:    indexNeed{rng: []string{"created_at"}},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (created_at DESC)"), filter: true, sorted: true},
+		{name: "cột chỉ nằm trong INCLUDE",
+			need:    indexNeed{eq: []string{"total"}},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (status) INCLUDE (total)"), sorted: true},
+		{name: "index biểu thức không khớp cột",
+			need:    indexNeed{eq: []string{"email"}},
+			indexes: defs("CREATE UNIQUE INDEX i ON public.users USING btree (lower((email)::text))"), sorted: true},
+		{name: "index một phần vẫn tính theo cột",
+			need:    indexNeed{eq: []string{"partner_id"}},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (partner_id) WHERE (deleted_at IS NULL)"), filter: true, sorted: true},
+		{name: "sắp xếp sau các cột điều kiện bằng",
+			need:    indexNeed{eq: []string{"partner_id", "status"}, sort: "created_at"},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (status, partner_id, created_at DESC)"), filter: true, sorted: true},
+		{name: "sắp xếp sau cột không phải điều kiện bằng",
+			need:    indexNeed{eq: []string{"partner_id"}, sort: "created_at"},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (partner_id, status, created_at)"), filter: true},
+		{name: "sắp xếp sau cột biểu thức",
+			need:    indexNeed{sort: "created_at"},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (lower(label), created_at)"), filter: true},
+		{name: "sắp xếp theo cột đầu, không có điều kiện",
+			need:    indexNeed{sort: "created_at"},
+			indexes: defs("CREATE INDEX i ON public.orders USING btree (created_at DESC)"), filter: true, sorted: true},
+		{name: "sắp xếp theo cột điều kiện bằng",
+			need:    indexNeed{eq: []string{"status"}, sort: "status"},
+			indexes: nil, sorted: true},
+		{name: "không có index",
+			need:    indexNeed{eq: []string{"status"}, sort: "created_at"},
+			indexes: nil},
+	}
+	for _, tt := range tests {
+		if got := tt.need.filterIndexed(tt.indexes); got != tt.filter {
+			t.Errorf("%s: filterIndexed = %v, muốn %v", tt.name, got, tt.filter)
+		}
+		if got := tt.need.sortIndexed(tt.indexes); got != tt.sorted {
+			t.Errorf("%s: sortIndexed = %v, muốn %v", tt.name, got, tt.sorted)
+		}
+	}
+}
+
+func TestAdviseIndexes(t *testing.T) {
+	r := NewRepository[proxyUser, uint](newTestDataSource(t, &proxyUser{}))
+	indexes := func(defs ...string) func(string) ([][]string, error) {
+		return func(table string) ([][]string, error) {
+			if table != "proxy_users" {
+				t.Errorf("bảng %s", table)
+			}
+			var columns [][]string
+			for _, def := range defs {
+				columns = append(columns, postgresIndexColumns(def))
+			}
+			return columns, nil
+		}
+	}
+	tests := []struct {
+		method  string
+		defs    []string
+		columns [][]string
+		reasons [][]string
+	}{
+		{method: "FindAllByStatusAndPartnerIdOrderByTotalDesc",
+			defs: []string{"CREATE UNIQUE INDEX proxy_users_pkey ON public.proxy_users USING btree (id)",
+				"CREATE INDEX i ON public.proxy_users USING btree (partner_id, status, total DESC)"}},
+		{method: "FindAllByStatusAndPartnerIdOrderByTotalDesc",
+			defs:    []string{"CREATE INDEX i ON public.proxy_users USING btree (status) INCLUDE (total)"},
+			columns: [][]string{{"status", "partner_id", "total"}}, reasons: [][]string{{IndexForSort}}},
+		{method: "FindAllByTotalGreaterThan",
+			defs:    []string{"CREATE INDEX i ON public.proxy_users USING btree (status, total)"},
+			columns: [][]string{{"total"}}, reasons: [][]string{{IndexForFilter}}},
+		// Nhiều nhóm OR: mỗi nhóm cần index riêng, OrderBy không tính
+		{method: "FindAllByStatusOrPartnerIdOrderByTotal",
+			defs:    []string{"CREATE INDEX i ON public.proxy_users USING btree (status)"},
+			columns: [][]string{{"partner_id"}}, reasons: [][]string{{IndexForFilter}}},
+	}
+	for _, tt := range tests {
+		f, err := r.newFinder(tt.method, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		advices, err := f.adviseIndexes(indexes(tt.defs...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var columns, reasons [][]string
+		for _, a := range advices {
+			columns = append(columns, a.Columns)
+			reasons = append(reasons, a.Reasons)
+		}
+		if !reflect.DeepEqual(columns, tt.columns) || !reflect.DeepEqual(reasons, tt.reasons) {
+			t.Errorf("%s: đề xuất %q %q, muốn %q %q", tt.method, columns, reasons, tt.columns, tt.reasons)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// callTags tag áp dụng cho mỗi lần gọi hàm dynamic:
+//   - `timeout:"500ms"`: deadline riêng của lời gọi (deadline sớm hơn của ctx vẫn được giữ)
+//   - `readonly:"true"`: chạy qua DataSource.ReadOnly (replica, hoặc transaction READ ONLY khi không có replica)
+type callTags struct {
+	timeout  time.Duration
+	readonly bool
+}
+
+// parseCallTags đọc tag timeout và readonly
+func parseCallTags(tag reflect.StructTag) (callTags, error) {
+	var c callTags
+	if v := tag.Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return c, fmt.Errorf("tag timeout %q không hợp lệ, dùng dạng 500ms, 2s", v)
+		}
+		c.timeout = d
+	}
+	if v := tag.Get("readonly"); v != "" {
+		readonly, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, fmt.Errorf("tag readonly %q không hợp lệ (true|false)", v)
+		}
+		c.readonly = readonly
+	}
+	return c, nil
+}
+
+// call chạy fn với ctx đã áp dụng timeout và readonly của hàm
+func (r *Repository[T, ID]) call(ctx context.Context, c callTags, fn func(ctx context.Context) error) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	if c.readonly {
+		return r.ReadOnly(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+// Call chạy fn với timeout và readonly khai báo trong tag, dùng cho code sinh bởi repogen
+// gọi thẳng NamedQuery, CallProcedure, CallFunction
+func (r *Repository[T, ID]) Call(ctx context.Context, tag reflect.StructTag, fn func(ctx context.Context) error) error {
+	c, err := parseCallTags(tag)
+	if err != nil {
+		return fmt.Errorf("repo: %w", err)
+	}
+	return r.call(ctx, c, fn)
+}
+
+// optimizerHints gợi ý cho bộ tối ưu theo dialect, khai báo bằng tag `hint:"..."` (mọi DB)
+// và `hint_mysql:"..."`, `hint_postgres:"..."`, ... (ưu tiên hơn hint chung)
+type optimizerHints map[string]string
+
+// parseHintTags đọc tag hint và hint_<dialect>
+func parseHintTags(tag reflect.StructTag) (optimizerHints, error) {
+	var hints optimizerHints
+	for _, dialect := range append([]string{""}, queryDialects...) {
+		key := "hint"
+		if dialect != "" {
+			key += "_" + dialect
+		}
+		v := strings.TrimSpace(tag.Get(key))
+		if v == "" {
+			continue
+		}
+		if strings.Contains(v, "*/") {
+			return nil, fmt.Errorf("tag %s không được chứa */", key)
+		}
+		if hints == nil {
+			hints = optimizerHints{}
+		}
+		hints[dialect] = v
+	}
+	return hints, nil
+}
+
+// apply gắn gợi ý của dialect đang dùng vào câu SELECT của q
+func (h optimizerHints) apply(q *gorm.DB) *gorm.DB {
+	if len(h) == 0 {
+		return q
+	}
+	dialect := q.Dialector.Name()
+	content, ok := h[dialect]
+	if !ok {
+		content = h[""]
+	}
+	if content == "" {
+		return q
+	}
+	return q.Clauses(optimizerHint{content: content, before: dialect == "postgres"})
+}
+
+// optimizerHint comment /*+ ... */: postgres (pg_hint_plan) đặt trước SELECT, các DB khác ngay sau SELECT
+type optimizerHint struct {
+	content string
+	before  bool
+}
+
+func (h optimizerHint) ModifyStatement(stmt *gorm.Statement) {
+	c := stmt.Clauses["SELECT"]
+	if h.before {
+		c.BeforeExpression = h
+	} else {
+		c.AfterNameExpression = h
+	}
+	stmt.Clauses["SELECT"] = c
+}
+
+func (h optimizerHint) Build(builder clause.Builder) {
+	builder.WriteString("/*+ " + h.content + " */")
+}
@@ -0,0 +1,601 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Các dialect khai báo được bằng "-- dialect:", theo tên Dialector của GORM
+var queryDialects = []string{"postgres", "mysql", "sqlite", "sqlserver"}
+
+// QueryRegistry các câu lệnh SQL có tên nạp từ file .sql, mỗi câu lệnh mở đầu bằng "-- name:",
+// có thể kèm "-- dialect:" để khai báo biến thể riêng cho từng DB (không có là biến thể mặc định):
+//
+//	-- name: FindActiveUsers
+//	SELECT * FROM user_tbl WHERE status = :status AND created_at > :since
+//
+//	-- name: FindActiveUsers
+//	-- dialect: mysql
+//	SELECT * FROM user_tbl USE INDEX (idx_status) WHERE status = :status AND created_at > :since
+//
+// Tham số viết dạng :name ("::" là ép kiểu của Postgres, không phải tham số). Trong chuỗi của biến thể mysql,
+// dấu \ escape ký tự sau nó (MySQL mặc định), ví dụ 'it\'s :x' không có tham số; biến thể mặc định theo chuẩn SQL
+// và được biên dịch thêm theo cách của MySQL để dùng khi repository chạy trên mysql.
+type QueryRegistry struct {
+	queries map[string]map[string]*namedQuery // tên -> dialect ("" là mặc định) -> câu lệnh
+}
+
+// namedQuery câu lệnh có tên đã biên dịch: các tham số :name được thay bằng ?
+type namedQuery struct {
+	name   string
+	source string // file:dòng khai báo
+	sql    string
+	params []string // tên tham số theo vị trí ?, một tên có thể lặp lại
+	names  []string // tên tham số không lặp, theo thứ tự xuất hiện lần đầu
+
+	mysql    *namedQuery // biến thể mặc định biên dịch với escape \ của MySQL, nil khi giống hệt bản chuẩn
+	mysqlErr error       // lỗi khi biên dịch biến thể mặc định theo cách của MySQL
+}
+
+// LoadQueries nạp các câu lệnh có tên từ fsys (thường là embed.FS), patterns theo fs.Glob,
+// không truyền pattern thì nạp mọi file .sql
+//
+//	//go:embed queries/*.sql
+//	var queryFiles embed.FS
+//	queries, err := repo.LoadQueries(queryFiles)
+func LoadQueries(fsys fs.FS, patterns ...string) (*QueryRegistry, error) {
+	var files []string
+	if len(patterns) == 0 {
+		err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && strings.HasSuffix(path, ".sql") {
+				files = append(files, path)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("repo: không có file nào khớp %q", pattern)
+		}
+		files = append(files, matches...)
+	}
+
+	q := &QueryRegistry{queries: map[string]map[string]*namedQuery{}}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		if err := q.parse(file, string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// queryBlock một khối "-- name:" đang đọc
+type queryBlock struct {
+	name     string
+	line     int
+	dialects []string
+	body     strings.Builder
+	started  bool // đã có dòng SQL (không tính comment)
+}
+
+// parse đọc các khối "-- name:" của một file
+func (q *QueryRegistry) parse(file, src string) error {
+	var block *queryBlock
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if comment, ok := strings.CutPrefix(trimmed, "--"); ok {
+			comment = strings.TrimSpace(comment)
+			if name, ok := strings.CutPrefix(comment, "name:"); ok {
+				if err := q.add(file, block); err != nil {
+					return err
+				}
+				block = &queryBlock{name: strings.TrimSpace(name), line: i + 1}
+				if !isIdentifier(block.name) {
+					return fmt.Errorf("repo: %s:%d: tên câu lệnh %q không hợp lệ", file, i+1, block.name)
+				}
+				continue
+			}
+			if dialects, ok := strings.CutPrefix(comment, "dialect:"); ok {
+				if block == nil || block.started {
+					return fmt.Errorf("repo: %s:%d: -- dialect: phải đặt ngay sau -- name:", file, i+1)
+				}
+				for _, d := range strings.Split(dialects, ",") {
+					d = strings.ToLower(strings.TrimSpace(d))
+					if !slices.Contains(queryDialects, d) {
+						return fmt.Errorf("repo: %s:%d: dialect %q không hợp lệ (%s)", file, i+1, d, strings.Join(queryDialects, "|"))
+					}
+					block.dialects = append(block.dialects, d)
+				}
+				continue
+			}
+		}
+		if block == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return fmt.Errorf("repo: %s:%d: câu lệnh nằm ngoài khối -- name:", file, i+1)
+			}
+			continue
+		}
+		block.started = block.started || (trimmed != "" && !strings.HasPrefix(trimmed, "--"))
+		block.body.WriteString(line)
+		block.body.WriteByte('\n')
+	}
+	return q.add(file, block)
+}
+
+// add biên dịch khối đã đọc xong và đăng ký theo từng dialect
+func (q *QueryRegistry) add(file string, block *queryBlock) error {
+	if block == nil {
+		return nil
+	}
+	source := fmt.Sprintf("%s:%d", file, block.line)
+	body := strings.TrimSpace(block.body.String())
+	if !block.started {
+		return fmt.Errorf("repo: %s: câu lệnh %s rỗng", source, block.name)
+	}
+	dialects := block.dialects
+	if len(dialects) == 0 {
+		dialects = []string{""}
+	}
+	variants := q.queries[block.name]
+	if variants == nil {
+		variants = map[string]*namedQuery{}
+		q.queries[block.name] = variants
+	}
+	for _, d := range dialects {
+		if prev, ok := variants[d]; ok {
+			return fmt.Errorf("repo: %s: câu lệnh %s%s đã khai báo ở %s", source, block.name, dialectLabel(d), prev.source)
+		}
+		nq, err := compileNamed(body, d == "mysql")
+		if err != nil {
+			if d == "" {
+				if _, mysqlErr := compileNamed(body, true); mysqlErr == nil {
+					err = fmt.Errorf("%w (chuỗi dùng \\ escape của MySQL, khai báo -- dialect: mysql)", err)
+				}
+			}
+			return fmt.Errorf("repo: %s: %w", source, err)
+		}
+		nq.name, nq.source = block.name, source
+		if d == "" {
+			mysql, err := compileNamed(body, true)
+			switch {
+			case err != nil:
+				nq.mysqlErr = fmt.Errorf("repo: %s: câu lệnh %s không dùng được với mysql: %w", source, block.name, err)
+			case mysql.sql != nq.sql || !slices.Equal(mysql.params, nq.params):
+				mysql.name, mysql.source = block.name, source
+				nq.mysql = mysql
+			}
+		}
+		variants[d] = nq
+	}
+	return nil
+}
+
+// Names tên các câu lệnh đã nạp, theo thứ tự chữ cái
+func (q *QueryRegistry) Names() []string {
+	names := make([]string, 0, len(q.queries))
+	for name := range q.queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SQL câu lệnh name cho dialect (biến thể riêng, không có thì biến thể mặc định), tham số :name đã thay bằng ?
+func (q *QueryRegistry) SQL(name, dialect string) (string, []string, error) {
+	nq, err := q.lookup(name, dialect)
+	if err != nil {
+		return "", nil, err
+	}
+	return nq.sql, slices.Clone(nq.params), nil
+}
+
+func (q *QueryRegistry) lookup(name, dialect string) (*namedQuery, error) {
+	variants, ok := q.queries[name]
+	if !ok {
+		return nil, fmt.Errorf("repo: không có câu lệnh %s", name)
+	}
+	if nq, ok := variants[dialect]; ok {
+		return nq, nil
+	}
+	nq, ok := variants[""]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("repo: câu lệnh %s không có biến thể cho %s", name, dialect)
+	case dialect == "mysql" && nq.mysqlErr != nil:
+		return nil, nq.mysqlErr
+	case dialect == "mysql" && nq.mysql != nil:
+		return nq.mysql, nil
+	}
+	return nq, nil
+}
+
+func dialectLabel(dialect string) string {
+	if dialect == "" {
+		return ""
+	}
+	return " (" + dialect + ")"
+}
+
+// compileNamed thay các tham số :name bằng ?, bỏ qua chuỗi, tên trong ngoặc kép, comment và "::".
+// Dấu ? ngoài chuỗi/comment bị từ chối vì gorm coi mọi ? là tham số.
+// backslash: trong chuỗi '...' và "..." dấu \ escape ký tự sau nó (MySQL)
+func compileNamed(src string, backslash bool) (*namedQuery, error) {
+	nq := &namedQuery{}
+	var b strings.Builder
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := closingQuote(src, i, backslash && c != '`')
+			if end < 0 {
+				return nil, fmt.Errorf("thiếu %c đóng", c)
+			}
+			b.WriteString(src[i : end+1])
+			i = end + 1
+		case strings.HasPrefix(src[i:], "--"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			b.WriteString(src[i : i+end])
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("thiếu */ đóng comment")
+			}
+			b.WriteString(src[i : i+end+2])
+			i += end + 2
+		case strings.HasPrefix(src[i:], "::"):
+			b.WriteString("::")
+			i += 2
+		case c == ':' && i+1 < len(src) && isIdentStart(rune(src[i+1])):
+			j := i + 1
+			for j < len(src) && isIdentPart(rune(src[j])) {
+				j++
+			}
+			name := src[i+1 : j]
+			nq.params = append(nq.params, name)
+			if !slices.Contains(nq.names, name) {
+				nq.names = append(nq.names, name)
+			}
+			b.WriteByte('?')
+			i = j
+		case c == '?':
+			return nil, fmt.Errorf("dấu ? ngoài chuỗi bị gorm coi là tham số, dùng :name cho tham số; " +
+				"toán tử JSONB ?, ?|, ?& của postgres thay bằng jsonb_exists, jsonb_exists_any, jsonb_exists_all")
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	nq.sql = b.String()
+	return nq, nil
+}
+
+// closingQuote vị trí dấu đóng của chuỗi mở tại start, -1 khi không có
+func closingQuote(src string, start int, backslash bool) int {
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case src[start]:
+			return i
+		}
+	}
+	return -1
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || ('0' <= r && r <= '9')
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !isIdentPart(r) || (i == 0 && !isIdentStart(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// bind giá trị của các tham số theo vị trí ?: args là một struct/map chứa các tham số theo tên,
+// hoặc giá trị từng tham số theo thứ tự xuất hiện lần đầu trong câu lệnh
+func (nq *namedQuery) bind(args []any) ([]any, error) {
+	values := make(map[string]any, len(nq.names))
+	if len(args) == 1 && isParamSource(reflect.TypeOf(args[0])) {
+		for _, name := range nq.names {
+			v, ok := paramValue(reflect.ValueOf(args[0]), name)
+			if !ok {
+				return nil, fmt.Errorf("repo: câu lệnh %s thiếu tham số :%s", nq.name, name)
+			}
+			values[name] = v
+		}
+	} else {
+		if len(args) != len(nq.names) {
+			return nil, fmt.Errorf("repo: câu lệnh %s cần %d tham số (%s), nhận %d", nq.name, len(nq.names), strings.Join(nq.names, ", "), len(args))
+		}
+		for i, name := range nq.names {
+			values[name] = args[i]
+		}
+	}
+	params := make([]any, len(nq.params))
+	for i, name := range nq.params {
+		params[i] = values[name]
+	}
+	return params, nil
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// isParamSource kiểu chứa các tham số theo tên: struct (trừ time.Time và driver.Valuer) hoặc map[string]V
+func isParamSource(t reflect.Type) bool {
+	if t == nil || t.Implements(valuerType) {
+		return false
+	}
+	t = indirectType(t)
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String
+	case reflect.Struct:
+		return t != reflect.TypeOf(time.Time{}) && !reflect.PointerTo(t).Implements(valuerType)
+	}
+	return false
+}
+
+// paramValue giá trị tham số name trong struct/map: key của map, hoặc field có tên, tên cột GORM
+// hoặc snake_case khớp với name (không phân biệt hoa thường)
+func paramValue(v reflect.Value, name string) (any, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Map {
+		value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !value.IsValid() {
+			return nil, false
+		}
+		return value.Interface(), true
+	}
+	key := normalizeParamName(name)
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		column := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")["COLUMN"]
+		if normalizeParamName(field.Name) == key || (column != "" && normalizeParamName(column) == key) {
+			value, err := v.FieldByIndexErr(field.Index)
+			if err != nil {
+				return nil, false
+			}
+			return value.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+func normalizeParamName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// NamedQueryRepository chạy câu lệnh có tên nạp bằng WithQueries, *Repository[T, ID] cài đặt sẵn
+type NamedQueryRepository interface {
+	NamedQuery(ctx context.Context, name string, dest any, params ...any) error
+	NamedExec(ctx context.Context, name string, params ...any) (int64, error)
+}
+
+var _ NamedQueryRepository = (*Repository[struct{}, int])(nil)
+
+// WithQueries các câu lệnh có tên dùng cho NamedQuery, NamedExec và field `repo:"@Query(name=...)"`
+func WithQueries(q *QueryRegistry) Option {
+	return func(o *options) {
+		o.queries = q
+	}
+}
+
+// namedQuery câu lệnh name cho dialect của repository
+func (r *Repository[T, ID]) namedQuery(name string) (*namedQuery, error) {
+	if r.opts.queries == nil {
+		return nil, fmt.Errorf("repo: chưa cấu hình câu lệnh có tên, dùng repo.WithQueries")
+	}
+	dialect := ""
+	if r.DataSource != nil && r.DB != nil {
+		dialect = r.Dialector.Name()
+	}
+	return r.opts.queries.lookup(name, dialect)
+}
+
+// NamedQuery chạy câu lệnh có tên và ghi kết quả vào dest: pointer tới slice, struct, pointer struct
+// (ErrNotFound khi không có dòng nào) hoặc giá trị đơn. params xem QueryRegistry:
+//
+//	var users []UserModel
+//	err := r.NamedQuery(ctx, "FindActiveUsers", &users, map[string]any{"status": "active", "since": since})
+//	err = r.NamedQuery(ctx, "FindActiveUsers", &users, "active", since) // theo thứ tự :status, :since
+func (r *Repository[T, ID]) NamedQuery(ctx context.Context, name string, dest any, params ...any) error {
+	nq, err := r.namedQuery(name)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("repo: NamedQuery %s: dest phải là pointer khác nil", name)
+	}
+	args, err := nq.bind(params)
+	if err != nil {
+		return err
+	}
+	if err := scanResult(r.Conn(ctx).Raw(nq.sql, args...), rv); err != nil {
+		return err
+	}
+	r.track(ctx, dest)
+	return nil
+}
+
+// NamedExec chạy câu lệnh có tên không trả về dòng (INSERT, UPDATE, DELETE), trả về số dòng bị ảnh hưởng
+func (r *Repository[T, ID]) NamedExec(ctx context.Context, name string, params ...any) (int64, error) {
+	nq, err := r.namedQuery(name)
+	if err != nil {
+		return 0, err
+	}
+	args, err := nq.bind(params)
+	if err != nil {
+		return 0, err
+	}
+	res := r.Conn(ctx).Exec(nq.sql, args...)
+	return res.RowsAffected, res.Error
+}
+
+// scanResult ghi kết quả truy vấn q vào dest (pointer): slice, struct, pointer struct (ErrNotFound khi không có dòng nào)
+// hoặc giá trị đơn, xem isScalarResult
+func scanResult(q *gorm.DB, dest reflect.Value) error {
+	out := dest.Type().Elem()
+	switch {
+	case isScalarResult(out):
+		value := nullableScan(out)
+		if err := scanRow(q, value.Interface()); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		dest.Elem().Set(nullableValue(value, out))
+		return nil
+	case out.Kind() == reflect.Slice:
+		return q.Scan(dest.Interface()).Error
+	case out.Kind() == reflect.Ptr:
+		res := reflect.New(out.Elem())
+		if err := scanResult(q, res); err != nil {
+			return err
+		}
+		dest.Elem().Set(res)
+		return nil
+	}
+	res := q.Scan(dest.Interface())
+	if res.Error == nil && res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return res.Error
+}
+
+// scanRow đọc dòng đầu tiên của q, DryRun không chạy truy vấn nên không có dòng nào
+func scanRow(q *gorm.DB, dest ...any) error {
+	row := q.Row()
+	if row == nil {
+		return gorm.ErrDryRunModeUnsupported
+	}
+	return row.Scan(dest...)
+}
+
+// isScalarResult kết quả là giá trị của cột đầu tiên (int64, string, time.Time, *float64, []byte, ...),
+// không phải slice dòng hay struct nhận một dòng
+func isScalarResult(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Ptr:
+		return !isRowStruct(t.Elem())
+	}
+	return !isRowStruct(t)
+}
+
+// isRowStruct struct nhận một dòng kết quả theo tên cột (không phải time.Time hay kiểu tự Scan)
+func isRowStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// parseQueryTag đọc tag repo của hàm dynamic: "@Query" (truy vấn từ tên hàm) hoặc "@Query(name=X)" (câu lệnh có tên)
+func parseQueryTag(tag string) (name string, ok bool, err error) {
+	if tag == "@Query" {
+		return "", true, nil
+	}
+	arg, found := strings.CutPrefix(tag, "@Query(")
+	if !found {
+		return "", false, nil
+	}
+	arg, found = strings.CutSuffix(arg, ")")
+	name, hasName := strings.CutPrefix(strings.TrimSpace(arg), "name=")
+	if name = strings.TrimSpace(name); !found || !hasName || !isIdentifier(name) {
+		return "", false, fmt.Errorf("tag %q không hợp lệ, dùng @Query hoặc @Query(name=QueryName)", tag)
+	}
+	return name, true, nil
+}
+
+// makeNamed tạo func cho field `repo:"@Query(name=X)"`: (ctx, params...) (result, error) chạy NamedQuery,
+// (ctx, params...) error chạy NamedExec. Tham số là một struct/map, hoặc từng giá trị theo thứ tự xuất hiện
+func (r *Repository[T, ID]) makeNamed(name string, funcType reflect.Type, tag reflect.StructTag) (reflect.Value, error) {
+	nq, err := r.namedQuery(name)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	calls, err := parseCallTags(tag)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	errType := reflect.TypeOf((*error)(nil)).Elem()
+	exec := funcType.NumOut() == 1 && funcType.Out(0) == errType
+	if !exec && (funcType.NumOut() != 2 || funcType.Out(1) != errType) {
+		return reflect.Value{}, fmt.Errorf("câu lệnh có tên phải trả về (result, error) hoặc error")
+	}
+	if n := funcType.NumIn() - 1; !(n == 1 && isParamSource(funcType.In(1))) && n != len(nq.names) {
+		return reflect.Value{}, fmt.Errorf("câu lệnh %s cần %d tham số (%s), hàm nhận %d", name, len(nq.names), strings.Join(nq.names, ", "), n)
+	}
+
+	return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+		ctx := args[0].Interface().(context.Context)
+		params := make([]any, 0, len(args)-1)
+		for _, arg := range args[1:] {
+			params = append(params, arg.Interface())
+		}
+		if exec {
+			err := r.call(ctx, calls, func(ctx context.Context) error {
+				_, err := r.NamedExec(ctx, name, params...)
+				return err
+			})
+			if err != nil {
+				return []reflect.Value{reflect.ValueOf(err)}
+			}
+			return []reflect.Value{reflect.Zero(errType)}
+		}
+		res := reflect.New(funcType.Out(0))
+		err := r.call(ctx, calls, func(ctx context.Context) error {
+			return r.NamedQuery(ctx, name, res.Interface(), params...)
+		})
+		if err != nil {
+			return []reflect.Value{reflect.Zero(funcType.Out(0)), reflect.ValueOf(err)}
+		}
+		return []reflect.Value{res.Elem(), reflect.Zero(errType)}
+	}), nil
+}
@@ -0,0 +1,195 @@
+package repo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompileNamed(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		backslash bool
+		sql       string
+		params    []string
+		err       string
+	}{
+		{name: "tham số", src: "SELECT * FROM t WHERE a = :a AND b > :b_2",
+			sql: "SELECT * FROM t WHERE a = ? AND b > ?", params: []string{"a", "b_2"}},
+		{name: "tham số lặp lại", src: "WHERE a = :a OR b = :a",
+			sql: "WHERE a = ? OR b = ?", params: []string{"a", "a"}},
+		{name: "ép kiểu ::", src: "WHERE created_at > :since::timestamptz AND id = :id::uuid",
+			sql: "WHERE created_at > ?::timestamptz AND id = ?::uuid", params: []string{"since", "id"}},
+		{name: "chuỗi", src: "WHERE a = ':x' AND b = :b",
+			sql: "WHERE a = ':x' AND b = ?", params: []string{"b"}},
+		{name: "nháy đơn nhân đôi", src: "WHERE a = 'it''s :x' AND b = :b",
+			sql: "WHERE a = 'it''s :x' AND b = ?", params: []string{"b"}},
+		{name: "tên trong ngoặc kép", src: `SELECT "a:b", ` + "`c:d`" + ` FROM t WHERE e = :e`,
+			sql: `SELECT "a:b", ` + "`c:d`" + ` FROM t WHERE e = ?`, params: []string{"e"}},
+		{name: "comment --", src: "SELECT 1 -- :x\nWHERE a = :a",
+			sql: "SELECT 1 -- :x\nWHERE a = ?", params: []string{"a"}},
+		{name: "comment -- cuối câu", src: "WHERE a = :a -- :x",
+			sql: "WHERE a = ? -- :x", params: []string{"a"}},
+		{name: "comment /* */", src: "SELECT /* :x ' */ a FROM t WHERE a = :a",
+			sql: "SELECT /* :x ' */ a FROM t WHERE a = ?", params: []string{"a"}},
+		{name: "dấu : không phải tham số", src: "WHERE t = '10:00' AND a = : AND b = :1",
+			sql: "WHERE t = '10:00' AND a = : AND b = :1"},
+		{name: "? trong chuỗi, tên và comment", src: `SELECT '?', "a?" FROM t -- ?` + "\nWHERE b = :b /* ?| */",
+			sql: `SELECT '?', "a?" FROM t -- ?` + "\nWHERE b = ? /* ?| */", params: []string{"b"}},
+		{name: "? ngoài chuỗi", src: "WHERE a = ?", err: "dấu ? ngoài chuỗi"},
+		{name: "toán tử JSONB ?", src: "WHERE tags ? :tag", err: "jsonb_exists"},
+		{name: "toán tử JSONB ?|", src: "WHERE tags ?| :tags", err: "jsonb_exists_any"},
+		{name: "thiếu nháy đóng", src: "WHERE a = 'x", err: "thiếu ' đóng"},
+		{name: "thiếu ngoặc kép đóng", src: `SELECT "a FROM t`, err: `thiếu " đóng`},
+		{name: "thiếu */", src: "SELECT /* a", err: "thiếu */ đóng"},
+		{name: "chuẩn: \\ không escape", src: `WHERE a = 'C:\' AND b = :b`,
+			sql: `WHERE a = 'C:\' AND b = ?`, params: []string{"b"}},
+		{name: "chuẩn: \\' kết thúc chuỗi", src: `WHERE a = 'it\'s :x'`, err: "thiếu ' đóng"},
+		{name: "mysql: \\' trong chuỗi", src: `WHERE a = 'it\'s :x' AND b = :b`, backslash: true,
+			sql: `WHERE a = 'it\'s :x' AND b = ?`, params: []string{"b"}},
+		{name: "mysql: \\\" trong chuỗi", src: `WHERE a = "say \":x\"" AND b = :b`, backslash: true,
+			sql: `WHERE a = "say \":x\"" AND b = ?`, params: []string{"b"}},
+		{name: "mysql: \\\\ cuối chuỗi", src: `WHERE a = 'C:\\' AND b = :b`, backslash: true,
+			sql: `WHERE a = 'C:\\' AND b = ?`, params: []string{"b"}},
+		{name: "mysql: backquote không escape", src: "SELECT `a\\` FROM t WHERE b = :b", backslash: true,
+			sql: "SELECT `a\\` FROM t WHERE b = ?", params: []string{"b"}},
+		{name: "mysql: nháy đơn nhân đôi", src: "WHERE a = 'it''s :x' AND b = :b", backslash: true,
+			sql: "WHERE a = 'it''s :x' AND b = ?", params: []string{"b"}},
+		{name: "mysql: \\ ở cuối", src: `WHERE a = 'x\'`, backslash: true, err: "thiếu ' đóng"},
+	}
+	for _, tt := range tests {
+		nq, err := compileNamed(tt.src, tt.backslash)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: lỗi = %v, muốn chứa %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if nq.sql != tt.sql || !reflect.DeepEqual(nq.params, tt.params) {
+			t.Errorf("%s: compileNamed = %q %q, muốn %q %q", tt.name, nq.sql, nq.params, tt.sql, tt.params)
+		}
+	}
+}
+
+func TestLoadQueries(t *testing.T) {
+	fsys := fstest.MapFS{
+		"queries/users.sql": {Data: []byte(`-- Các câu lệnh của user
+-- name: FindActive
+SELECT * FROM users WHERE status = :status AND created_at > :since
+
+-- name: FindActive
+-- dialect: postgres, sqlite
+SELECT * FROM users WHERE status = :status AND created_at > :since::timestamptz
+
+-- name: FindActive
+-- dialect: mysql
+SELECT * FROM users USE INDEX (idx_status) WHERE status = :status AND created_at > :since
+`)},
+		"queries/notes.sql": {Data: []byte(`-- name: FindNotes
+SELECT * FROM notes WHERE body LIKE 'a\' :x \'b' AND id = :id
+
+-- name: DeleteNotes
+DELETE FROM notes WHERE body = 'it''s' AND id = :id
+`)},
+		"other/readme.txt": {Data: []byte("không phải sql")},
+	}
+	q, err := LoadQueries(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := q.Names(); !reflect.DeepEqual(names, []string{"DeleteNotes", "FindActive", "FindNotes"}) {
+		t.Errorf("Names = %q", names)
+	}
+
+	tests := []struct {
+		name, dialect, sql string
+		params             []string
+		err                string
+	}{
+		{name: "FindActive", dialect: "postgres",
+			sql: "SELECT * FROM users WHERE status = ? AND created_at > ?::timestamptz", params: []string{"status", "since"}},
+		{name: "FindActive", dialect: "sqlite",
+			sql: "SELECT * FROM users WHERE status = ? AND created_at > ?::timestamptz", params: []string{"status", "since"}},
+		{name: "FindActive", dialect: "mysql",
+			sql: "SELECT * FROM users USE INDEX (idx_status) WHERE status = ? AND created_at > ?", params: []string{"status", "since"}},
+		{name: "FindActive", dialect: "sqlserver",
+			sql: "SELECT * FROM users WHERE status = ? AND created_at > ?", params: []string{"status", "since"}},
+		// Biến thể mặc định: chuẩn SQL ở các dialect khác, escape \ khi chạy trên mysql
+		{name: "FindNotes", dialect: "mysql",
+			sql: `SELECT * FROM notes WHERE body LIKE 'a\' :x \'b' AND id = ?`, params: []string{"id"}},
+		{name: "FindNotes", dialect: "postgres",
+			sql: `SELECT * FROM notes WHERE body LIKE 'a\' ? \'b' AND id = ?`, params: []string{"x", "id"}},
+		{name: "DeleteNotes", dialect: "mysql",
+			sql: "DELETE FROM notes WHERE body = 'it''s' AND id = ?", params: []string{"id"}},
+		{name: "Missing", dialect: "mysql", err: "không có câu lệnh Missing"},
+	}
+	for _, tt := range tests {
+		sql, params, err := q.SQL(tt.name, tt.dialect)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s (%s): lỗi = %v, muốn chứa %q", tt.name, tt.dialect, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || sql != tt.sql || !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("%s (%s): SQL = %q %q %v, muốn %q %q", tt.name, tt.dialect, sql, params, err, tt.sql, tt.params)
+		}
+	}
+}
+
+func TestLoadQueriesErrors(t *testing.T) {
+	tests := []struct {
+		name, src, err string
+	}{
+		{name: "khai báo trùng", src: "-- name: A\nSELECT 1\n-- name: A\nSELECT 2\n",
+			err: "q.sql:3: câu lệnh A đã khai báo ở q.sql:1"},
+		{name: "dialect trùng", src: "-- name: A\n-- dialect: mysql\nSELECT 1\n-- name: A\n-- dialect: postgres, mysql\nSELECT 2\n",
+			err: "câu lệnh A (mysql) đã khai báo ở q.sql:1"},
+		{name: "dialect sau SQL", src: "-- name: A\nSELECT 1\n-- dialect: mysql\n",
+			err: "q.sql:3: -- dialect: phải đặt ngay sau -- name:"},
+		{name: "dialect ngoài khối", src: "-- dialect: mysql\n-- name: A\nSELECT 1\n",
+			err: "q.sql:1: -- dialect: phải đặt ngay sau -- name:"},
+		{name: "dialect không hợp lệ", src: "-- name: A\n-- dialect: oracle\nSELECT 1\n",
+			err: `dialect "oracle" không hợp lệ`},
+		{name: "tên không hợp lệ", src: "-- name: find-all\nSELECT 1\n",
+			err: `tên câu lệnh "find-all" không hợp lệ`},
+		{name: "ngoài khối", src: "SELECT 1\n-- name: A\nSELECT 2\n",
+			err: "q.sql:1: câu lệnh nằm ngoài khối -- name:"},
+		{name: "rỗng", src: "-- name: A\n-- chỉ có comment\n-- name: B\nSELECT 1\n",
+			err: "câu lệnh A rỗng"},
+		{name: "chuỗi không đóng", src: "-- name: A\nSELECT 'x\n",
+			err: "q.sql:1: thiếu ' đóng"},
+		{name: "dấu ? ngoài chuỗi", src: "-- name: A\nSELECT * FROM t WHERE tags ?& :tags\n",
+			err: "q.sql:1: dấu ? ngoài chuỗi"},
+		{name: "escape của mysql ở biến thể mặc định", src: "-- name: A\nSELECT 'it\\'s'\n",
+			err: "khai báo -- dialect: mysql"},
+	}
+	for _, tt := range tests {
+		_, err := LoadQueries(fstest.MapFS{"q.sql": {Data: []byte(tt.src)}})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: lỗi = %v, muốn chứa %q", tt.name, err, tt.err)
+		}
+	}
+
+	// Biến thể mặc định hợp lệ theo chuẩn SQL nhưng không dùng được trên mysql
+	q, err := LoadQueries(fstest.MapFS{"q.sql": {Data: []byte("-- name: A\nSELECT 'C:\\' || :x\n")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := q.SQL("A", "postgres"); err != nil {
+		t.Errorf("postgres: %v", err)
+	}
+	if _, _, err := q.SQL("A", "mysql"); err == nil || !strings.Contains(err.Error(), "không dùng được với mysql") {
+		t.Errorf("mysql: lỗi = %v", err)
+	}
+
+	if _, err := LoadQueries(fstest.MapFS{}, "queries/*.sql"); err == nil || !strings.Contains(err.Error(), "không có file nào khớp") {
+		t.Errorf("pattern không khớp: lỗi = %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Opt tham số tùy chọn của hàm dynamic: khi không có giá trị, điều kiện tương ứng trong tên hàm bị bỏ khỏi WHERE.
+// Zero value là không có giá trị. Khi mọi tham số đều vắng mặt, truy vấn không còn điều kiện nào và đọc cả bảng.
+//
+//	FindAllByStatusAndPartnerId func(ctx context.Context, status repo.Opt[string], partnerId repo.Opt[string]) ([]UserModel, error)
+//	users, err := r.FindAllByStatusAndPartnerId(ctx, repo.Some("active"), repo.None[string]())
+type Opt[T any] struct {
+	value T
+	ok    bool
+}
+
+// Some tham số tùy chọn có giá trị v
+func Some[T any](v T) Opt[T] {
+	return Opt[T]{value: v, ok: true}
+}
+
+// None tham số tùy chọn không có giá trị
+func None[T any]() Opt[T] {
+	return Opt[T]{}
+}
+
+// OptOf tham số tùy chọn từ pointer, nil là không có giá trị
+func OptOf[T any](p *T) Opt[T] {
+	if p == nil {
+		return Opt[T]{}
+	}
+	return Some(*p)
+}
+
+// Get trả về giá trị và cho biết có giá trị hay không
+func (o Opt[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+func (o Opt[T]) optional() (any, bool) {
+	return o.value, o.ok
+}
+
+// optionalParam kiểu tham số tùy chọn (Opt[T])
+type optionalParam interface {
+	optional() (any, bool)
+}
+
+var optionalParamType = reflect.TypeOf((*optionalParam)(nil)).Elem()
+
+// isOptionalParam tham số kiểu Opt[T], hoặc pointer khi hàm có tag `optional:"true"`
+func isOptionalParam(t reflect.Type, tagged bool) bool {
+	return t.Implements(optionalParamType) || (tagged && t.Kind() == reflect.Ptr)
+}
+
+// argBinder đọc giá trị thật của một tham số và cho biết tham số có mặt không
+type argBinder func(v reflect.Value) (any, bool)
+
+// paramBinder chọn cách đọc tham số kiểu t một lần khi wiring: Opt[T] không có giá trị
+// hoặc pointer nil (khi optional) là vắng mặt
+func paramBinder(t reflect.Type, optional bool) argBinder {
+	switch {
+	case t.Implements(optionalParamType):
+		return func(v reflect.Value) (any, bool) {
+			return v.Interface().(optionalParam).optional()
+		}
+	case optional && t.Kind() == reflect.Ptr:
+		return func(v reflect.Value) (any, bool) {
+			if v.IsNil() {
+				return nil, false
+			}
+			return v.Elem().Interface(), true
+		}
+	}
+	return func(v reflect.Value) (any, bool) {
+		return v.Interface(), true
+	}
+}
+
+// parseOptionalTag đọc tag `optional:"true"`: tham số pointer nil được coi là vắng mặt
+func parseOptionalTag(tag string, funcType reflect.Type) (tagged, hasOptional bool, err error) {
+	switch tag {
+	case "", "false":
+	case "true":
+		tagged = true
+	default:
+		return false, false, fmt.Errorf("tag optional không hợp lệ %q (true|false)", tag)
+	}
+	for j := 1; j < funcType.NumIn(); j++ {
+		hasOptional = hasOptional || isOptionalParam(funcType.In(j), tagged)
+	}
+	if tagged && !hasOptional {
+		return false, false, fmt.Errorf("tag optional cần ít nhất một tham số pointer hoặc repo.Opt")
+	}
+	return tagged, hasOptional, nil
+}
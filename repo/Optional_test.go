@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindConditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		where   string
+		params  []any
+		present []bool
+		clauses []string
+		args    []any
+	}{
+		{name: "đủ tham số", where: "StatusOrPartnerId", params: []any{"a", "p"},
+			clauses: []string{"(status = ?)", "(partner_id = ?)"}, args: []any{"a", "p"}},
+		{name: "nhóm OR rỗng bị bỏ", where: "StatusOrPartnerId", params: []any{"a", "p"}, present: []bool{false, true},
+			clauses: []string{"(partner_id = ?)"}, args: []any{"p"}},
+		{name: "nhóm AND rỗng bị bỏ", where: "StatusAndTotalOrPartnerId", params: []any{"a", 1, "p"}, present: []bool{false, false, true},
+			clauses: []string{"(partner_id = ?)"}, args: []any{"p"}},
+		{name: "bỏ một điều kiện trong nhóm", where: "StatusAndTotalOrPartnerId", params: []any{"a", 1, "p"}, present: []bool{true, false, true},
+			clauses: []string{"(status = ?)", "(partner_id = ?)"}, args: []any{"a", "p"}},
+		{name: "Between thiếu một tham số bị bỏ", where: "TotalBetweenAndStatus", params: []any{1, nil, "a"}, present: []bool{true, false, true},
+			clauses: []string{"(status = ?)"}, args: []any{"a"}},
+		{name: "Between đủ tham số", where: "TotalBetweenAndStatus", params: []any{1, 9, nil}, present: []bool{true, true, false},
+			clauses: []string{"(total BETWEEN ? AND ?)"}, args: []any{1, 9}},
+		{name: "IsNull giữ lại", where: "DeletedAtIsNullAndStatus", params: []any{nil}, present: []bool{false},
+			clauses: []string{"(deleted_at IS NULL)"}, args: []any{}},
+		{name: "IsNull ở nhóm OR", where: "StatusOrDeletedAtIsNull", params: []any{nil}, present: []bool{false},
+			clauses: []string{"(deleted_at IS NULL)"}, args: []any{}},
+		{name: "vắng mặt hết: không lọc", where: "StatusOrPartnerId", params: []any{nil, nil}, present: []bool{false, false},
+			clauses: []string{}, args: []any{}},
+	}
+	for _, tt := range tests {
+		groups, err := parseWhereConditions(tt.where, snakeCaseColumn)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		clauses, args := bindConditions(groups, tt.params, tt.present)
+		if !reflect.DeepEqual(clauses, tt.clauses) || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: bindConditions = %q %v, muốn %q %v", tt.name, clauses, args, tt.clauses, tt.args)
+		}
+	}
+}
+
+func TestFinderOptional(t *testing.T) {
+	ds := newTestDataSource(t, &proxyUser{})
+	r := NewRepository[proxyUser, uint](ds)
+	ctx := context.Background()
+	for _, u := range []proxyUser{{Status: "active", PartnerId: "p1", Total: 1}, {Status: "active", PartnerId: "p2", Total: 5}, {Status: "locked", PartnerId: "p1", Total: 9}} {
+		if err := r.Insert(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := r.Finder("FindAllByStatusAndTotalBetweenOrPartnerId", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		args  []any
+		where string
+		want  int
+	}{
+		{name: "đủ tham số", args: []any{"active", 2, 9, "p1"},
+			where: "(status = ? AND total BETWEEN ? AND ?) OR (partner_id = ?)", want: 3},
+		{name: "Between thiếu cận trên", args: []any{Some("active"), Some(2), None[int](), None[string]()},
+			where: "(status = ?)", want: 2},
+		{name: "nhóm đầu vắng mặt", args: []any{None[string](), None[int](), None[int](), Some("p2")},
+			where: "(partner_id = ?)", want: 1},
+		{name: "vắng mặt hết: cả bảng", args: []any{None[string](), None[int](), None[int](), None[string]()},
+			where: "", want: 3},
+	}
+	unquote := strings.NewReplacer("`proxy_users`.", "", "`", "")
+	for _, tt := range tests {
+		qp, _, err := f.bind(unwrapArgs(tt.args))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if where := unquote.Replace(qp.where); where != tt.where {
+			t.Errorf("%s: WHERE = %q, muốn %q", tt.name, where, tt.where)
+		}
+		users, err := f.All(ctx, 0, tt.args...)
+		if err != nil || len(users) != tt.want {
+			t.Errorf("%s: %d bản ghi (%v), muốn %d", tt.name, len(users), err, tt.want)
+		}
+	}
+	// bind không được sửa QueryParts dùng chung của Finder
+	if !strings.Contains(f.qp.where, "BETWEEN") || len(f.qp.WhereClauses) != 2 {
+		t.Errorf("QueryParts của Finder bị thay đổi: %q", f.qp.where)
+	}
+	if _, _, err := f.bind([]any{"active"}, nil); err == nil || !strings.Contains(err.Error(), "không khớp") {
+		t.Errorf("thiếu tham số: lỗi = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Option cấu hình Repository, truyền vào NewRepository hoặc With
+type Option func(o *options)
+
+type options struct {
+	preloads      []preload
+	lock          *lockSpec
+	idChunkSize   int
+	deleted       deletedScope
+	history       bool
+	snowflakeNode int64
+	fetchSize     int
+	queries       *QueryRegistry
+}
+
+// preload một quan hệ cần nạp sẵn khi đọc entity
+type preload struct {
+	name  string
+	join  bool // true: nạp bằng JOIN trong cùng truy vấn, false: truy vấn riêng
+	conds []any
+}
+
+// Preload nạp sẵn quan hệ bằng truy vấn riêng (tránh N+1), hỗ trợ quan hệ lồng nhau "Orders.Items"
+func Preload(association string, conds ...any) Option {
+	return func(o *options) {
+		o.preloads = append(o.preloads, preload{name: association, conds: conds})
+	}
+}
+
+// JoinPreload nạp sẵn quan hệ has-one/belongs-to trong cùng truy vấn bằng JOIN
+func JoinPreload(association string, conds ...any) Option {
+	return func(o *options) {
+		o.preloads = append(o.preloads, preload{name: association, join: true, conds: conds})
+	}
+}
+
+func (o options) clone() options {
+	o.preloads = append([]preload(nil), o.preloads...)
+	return o
+}
+
+// applyPreloads gắn các preload vào truy vấn đọc
+func applyPreloads(q *gorm.DB, preloads []preload) *gorm.DB {
+	for _, p := range preloads {
+		if p.join {
+			q = q.Joins(p.name, p.conds...)
+		} else {
+			q = q.Preload(p.name, p.conds...)
+		}
+	}
+	return q
+}
+
+// parsePreloadTag đọc tag `preload:"Orders,Partner"` và `fetch:"join"` của hàm dynamic
+func parsePreloadTag(preloadTag, fetchTag string) ([]preload, error) {
+	if preloadTag == "" {
+		if fetchTag != "" {
+			return nil, fmt.Errorf("tag fetch chỉ dùng kèm tag preload")
+		}
+		return nil, nil
+	}
+	var join bool
+	switch fetchTag {
+	case "", "select":
+	case "join":
+		join = true
+	default:
+		return nil, fmt.Errorf("tag fetch không hợp lệ %q (select|join)", fetchTag)
+	}
+	var preloads []preload
+	for _, name := range strings.Split(preloadTag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			preloads = append(preloads, preload{name: name, join: join})
+		}
+	}
+	return preloads, nil
+}
+
+// validatePreload kiểm tra quan hệ tồn tại trong schema, JOIN chỉ dùng được cho has-one/belongs-to
+func validatePreload(s *schema.Schema, p preload) error {
+	for _, name := range strings.Split(p.name, ".") {
+		rel, ok := s.Relationships.Relations[name]
+		if !ok {
+			return fmt.Errorf("không tìm thấy quan hệ %s trong %s", p.name, s.Name)
+		}
+		if p.join && rel.Type != schema.HasOne && rel.Type != schema.BelongsTo {
+			return fmt.Errorf("quan hệ %s (%s) không nạp được bằng JOIN, dùng fetch:\"select\"", p.name, rel.Type)
+		}
+		s = rel.FieldSchema
+	}
+	return nil
+}
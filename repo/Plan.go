@@ -0,0 +1,158 @@
+package repo
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Plan kế hoạch truy vấn của một hàm dynamic, biên dịch một lần khi FillFuncFields/Finder và dùng lại cho mọi lần gọi.
+// Plan trả về là bản sao, sửa không ảnh hưởng tới Finder.
+type Plan struct {
+	Method     string            `json:"method"`
+	Kind       string            `json:"kind"`             // find | aggregate
+	Result     string            `json:"result,omitempty"` // kiểu kết quả khi wiring bằng FillFuncFields
+	Columns    []string          `json:"columns"`          // các cột đã resolve (điều kiện, sắp xếp, group, aggregate)
+	Joins      []string          `json:"joins,omitempty"`
+	Where      string            `json:"where,omitempty"`      // WHERE đầy đủ, "?" là vị trí tham số
+	Conditions [][]string        `json:"conditions,omitempty"` // các nhóm điều kiện AND, nối với nhau bằng OR
+	Params     int               `json:"params"`               // số tham số điều kiện (không tính ctx, repo.Limit)
+	Select     string            `json:"select,omitempty"`
+	Distinct   bool              `json:"distinct,omitempty"`
+	OrderBy    string            `json:"orderBy,omitempty"`
+	Limit      int               `json:"limit,omitempty"`
+	GroupBy    []string          `json:"groupBy,omitempty"`
+	Preloads   []string          `json:"preloads,omitempty"`
+	Lock       string            `json:"lock,omitempty"`
+	Timeout    string            `json:"timeout,omitempty"`
+	ReadOnly   bool              `json:"readOnly,omitempty"`
+	Hints      map[string]string `json:"hints,omitempty"` // theo dialect, "" là hint chung
+}
+
+// Kind của Plan, cũng là kết quả của MethodKind
+const (
+	PlanFind      = "find"
+	PlanAggregate = "aggregate"
+)
+
+// Plan kế hoạch truy vấn đã biên dịch của Finder
+func (f *Finder[T, ID]) Plan() Plan {
+	p := Plan{
+		Method:  f.name,
+		Result:  f.result,
+		Columns: slices.Clone(f.columns),
+		Params:  f.params,
+	}
+	if f.calls.timeout > 0 {
+		p.Timeout = f.calls.timeout.String()
+	}
+	p.ReadOnly = f.calls.readonly
+	if len(f.hints) > 0 {
+		p.Hints = maps.Clone(f.hints)
+	}
+	var groups [][]whereCondition
+	if f.aggregate != nil {
+		p.Kind = PlanAggregate
+		p.Joins = slices.Clone(f.joins)
+		p.Select = f.aggregate.selectExpr()
+		p.GroupBy = slices.Clone(f.aggregate.groupBy)
+		groups = f.aggregate.conditions
+	} else {
+		p.Kind = PlanFind
+		p.Joins = slices.Clone(f.qp.Joins)
+		p.Select = f.qp.Select
+		p.Distinct = f.qp.Distinct
+		p.OrderBy = f.qp.OrderBy
+		p.Limit = f.qp.Limit
+		groups = f.qp.conditions
+		for _, pl := range f.preloads {
+			p.Preloads = append(p.Preloads, pl.name)
+		}
+		if f.lock != nil {
+			p.Lock = string(f.lock.mode)
+			if f.lock.wait != Wait {
+				p.Lock += "," + string(f.lock.wait)
+			}
+		}
+	}
+	for _, group := range groups {
+		conds := make([]string, len(group))
+		for i, cond := range group {
+			conds[i] = cond.sql
+		}
+		p.Conditions = append(p.Conditions, conds)
+	}
+	clauses, _ := bindConditions(groups, nil, nil)
+	p.Where = strings.Join(clauses, " OR ")
+	return p
+}
+
+// finderSet các Finder đã wiring của repository, dùng chung giữa các bản sao tạo bởi With
+type finderSet[T any, ID comparable] struct {
+	mu     sync.RWMutex
+	byName map[string]*Finder[T, ID]
+}
+
+func (s *finderSet[T, ID]) add(f *Finder[T, ID]) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.byName == nil {
+		s.byName = map[string]*Finder[T, ID]{}
+	}
+	s.byName[f.name] = f
+}
+
+// get Finder đã wiring theo tên hàm
+func (s *finderSet[T, ID]) get(name string) (*Finder[T, ID], bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.byName[name]
+	return f, ok
+}
+
+// all các Finder đã wiring, theo tên hàm
+func (s *finderSet[T, ID]) all() []*Finder[T, ID] {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	finders := make([]*Finder[T, ID], 0, len(s.byName))
+	for _, f := range s.byName {
+		finders = append(finders, f)
+	}
+	sort.Slice(finders, func(i, j int) bool { return finders[i].name < finders[j].name })
+	return finders
+}
+
+// Plans kế hoạch truy vấn của các hàm dynamic đã wiring (FillFuncFields, Finder, code sinh bởi repogen), theo tên hàm
+func (r *Repository[T, ID]) Plans() []Plan {
+	finders := r.finders.all()
+	if finders == nil {
+		return nil
+	}
+	plans := make([]Plan, len(finders))
+	for i, f := range finders {
+		plans[i] = f.Plan()
+	}
+	return plans
+}
+
+// recordColumns bọc resolve để ghi nhận các cột đã resolve (không trùng lặp)
+func recordColumns(resolve func(string) (string, error), columns *[]string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		column, err := resolve(name)
+		if err == nil && !slices.Contains(*columns, column) {
+			*columns = append(*columns, column)
+		}
+		return column, err
+	}
+}
@@ -0,0 +1,260 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xhkzeroone/go-database/db"
+	"gorm.io/gorm"
+)
+
+// OutParam tham số OUT của thủ tục lưu trữ, giá trị trả về được ghi vào dest theo tên
+type OutParam struct {
+	Name string
+}
+
+// Out đánh dấu vị trí tham số OUT name khi gọi CallProcedure
+//
+//	var res struct{ Status string; Balance int64 }
+//	err := r.CallProcedure(ctx, "transfer_funds", &res, from, to, amount, repo.Out("status"), repo.Out("balance"))
+func Out(name string) OutParam {
+	return OutParam{Name: name}
+}
+
+// Loại routine khai báo bằng tag repo
+const (
+	routineProcedure = "@Procedure"
+	routineFunction  = "@Function"
+)
+
+// routine thủ tục/hàm lưu trữ của field `repo:"@Procedure(name)"` hoặc `repo:"@Function(name)"`
+type routine struct {
+	kind string
+	name string
+	out  []string // tham số OUT (tag `out:"status,balance"`), đặt sau các tham số IN
+}
+
+// parseRoutineTag đọc tag @Procedure(name), @Function(name) (hoặc dạng name=...) và tag out
+func parseRoutineTag(tag, outTag string) (*routine, error) {
+	var rt *routine
+	for _, kind := range []string{routineProcedure, routineFunction} {
+		arg, ok := strings.CutPrefix(tag, kind+"(")
+		if !ok {
+			continue
+		}
+		arg, ok = strings.CutSuffix(arg, ")")
+		name, _ := strings.CutPrefix(strings.TrimSpace(arg), "name=")
+		if name = strings.TrimSpace(name); !ok || !isRoutineName(name) {
+			return nil, fmt.Errorf("tag %q không hợp lệ, dùng %s(name)", tag, kind)
+		}
+		rt = &routine{kind: kind, name: name}
+	}
+	if rt == nil {
+		return nil, nil
+	}
+	if outTag != "" {
+		if rt.kind != routineProcedure {
+			return nil, fmt.Errorf("tag out chỉ dùng với @Procedure")
+		}
+		for _, name := range strings.Split(outTag, ",") {
+			if name = strings.TrimSpace(name); !isIdentifier(name) {
+				return nil, fmt.Errorf("tên tham số OUT %q không hợp lệ", name)
+			}
+			rt.out = append(rt.out, name)
+		}
+	}
+	return rt, nil
+}
+
+// isRoutineName tên thủ tục/hàm, có thể kèm schema: public.transfer_funds
+func isRoutineName(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if !isIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRoutineDialect kiểm tra dialect hỗ trợ cách gọi: rows là kết quả dạng dòng (slice/struct), out là số tham số OUT
+func checkRoutineDialect(kind, dialect string, rows bool, out int) error {
+	switch {
+	case dialect == "sqlite":
+		return fmt.Errorf("sqlite không hỗ trợ thủ tục/hàm lưu trữ")
+	case kind == routineFunction && dialect == "mysql" && rows:
+		return fmt.Errorf("hàm của mysql chỉ trả về giá trị đơn, dùng @Procedure cho kết quả dạng dòng")
+	case out > 0 && dialect != "postgres" && dialect != "mysql":
+		return fmt.Errorf("tham số OUT chưa hỗ trợ trên %s", dialect)
+	}
+	return nil
+}
+
+// ProcedureRepository gọi thủ tục/hàm lưu trữ, *Repository[T, ID] cài đặt sẵn
+type ProcedureRepository interface {
+	CallProcedure(ctx context.Context, name string, dest any, args ...any) error
+	CallFunction(ctx context.Context, name string, dest any, args ...any) error
+}
+
+var _ ProcedureRepository = (*Repository[struct{}, int])(nil)
+
+// CallProcedure gọi thủ tục lưu trữ name: args là tham số IN theo thứ tự, repo.Out(name) đánh dấu tham số OUT.
+// dest (pointer, xem NamedQuery) nhận result set của thủ tục, hoặc các giá trị OUT theo tên khi có tham số OUT;
+// dest nil khi thủ tục không trả về gì. Postgres: CALL trả về một dòng chứa các tham số OUT/INOUT,
+// mysql: tham số OUT qua biến phiên @name, đọc lại trên cùng connection.
+func (r *Repository[T, ID]) CallProcedure(ctx context.Context, name string, dest any, args ...any) error {
+	if !isRoutineName(name) {
+		return fmt.Errorf("repo: tên thủ tục %q không hợp lệ", name)
+	}
+	conn := r.Conn(ctx)
+	dialect := conn.Dialector.Name()
+	var in []any
+	var placeholders, outs []string
+	for _, arg := range args {
+		out, ok := arg.(OutParam)
+		if !ok {
+			in = append(in, arg)
+			placeholders = append(placeholders, "?")
+			continue
+		}
+		if !isIdentifier(out.Name) {
+			return fmt.Errorf("repo: tên tham số OUT %q không hợp lệ", out.Name)
+		}
+		outs = append(outs, out.Name)
+		if dialect == "mysql" {
+			placeholders = append(placeholders, "@"+out.Name)
+		} else {
+			placeholders = append(placeholders, "NULL")
+		}
+	}
+	var rv reflect.Value
+	if dest != nil {
+		if rv = reflect.ValueOf(dest); rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return fmt.Errorf("repo: CallProcedure %s: dest phải là pointer khác nil", name)
+		}
+	}
+	if err := checkRoutineDialect(routineProcedure, dialect, dest != nil && !isScalarResult(rv.Type().Elem()), len(outs)); err != nil {
+		return fmt.Errorf("repo: CallProcedure %s: %w", name, err)
+	}
+
+	stmt := fmt.Sprintf("CALL %s(%s)", name, strings.Join(placeholders, ", "))
+	if dialect == "sqlserver" {
+		stmt = strings.TrimSpace(fmt.Sprintf("EXEC %s %s", name, strings.Join(placeholders, ", ")))
+	}
+	switch {
+	case dest == nil:
+		return conn.Exec(stmt, in...).Error
+	case dialect == "mysql" && len(outs) > 0:
+		// Biến phiên chỉ đọc được trên cùng connection: dùng connection của transaction hoặc giữ một connection riêng
+		fetch := func(tx *gorm.DB) error {
+			if err := tx.Exec(stmt, in...).Error; err != nil {
+				return err
+			}
+			columns := make([]string, len(outs))
+			for i, out := range outs {
+				columns[i] = fmt.Sprintf("@%s AS %s", out, out)
+			}
+			return scanResult(tx.Raw("SELECT "+strings.Join(columns, ", ")), rv)
+		}
+		if db.InTx(conn) {
+			return fetch(conn)
+		}
+		return conn.Connection(fetch)
+	}
+	if err := scanResult(conn.Raw(stmt, in...), rv); err != nil {
+		return err
+	}
+	r.track(ctx, dest)
+	return nil
+}
+
+// CallFunction gọi hàm lưu trữ name với các tham số args: dest giá trị đơn chạy SELECT name(...),
+// dest slice/struct chạy SELECT * FROM name(...) cho hàm trả về bảng (postgres, sqlserver)
+func (r *Repository[T, ID]) CallFunction(ctx context.Context, name string, dest any, args ...any) error {
+	if !isRoutineName(name) {
+		return fmt.Errorf("repo: tên hàm %q không hợp lệ", name)
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("repo: CallFunction %s: dest phải là pointer khác nil", name)
+	}
+	conn := r.Conn(ctx)
+	rows := !isScalarResult(rv.Type().Elem())
+	if err := checkRoutineDialect(routineFunction, conn.Dialector.Name(), rows, 0); err != nil {
+		return fmt.Errorf("repo: CallFunction %s: %w", name, err)
+	}
+	placeholders := make([]string, len(args))
+	for i, arg := range args {
+		if _, ok := arg.(OutParam); ok {
+			return fmt.Errorf("repo: CallFunction %s: hàm không có tham số OUT", name)
+		}
+		placeholders[i] = "?"
+	}
+	stmt := fmt.Sprintf("SELECT %s(%s)", name, strings.Join(placeholders, ", "))
+	if rows {
+		stmt = fmt.Sprintf("SELECT * FROM %s(%s)", name, strings.Join(placeholders, ", "))
+	}
+	if err := scanResult(conn.Raw(stmt, args...), rv); err != nil {
+		return err
+	}
+	r.track(ctx, dest)
+	return nil
+}
+
+// makeRoutine tạo func cho field @Procedure/@Function: tham số của hàm (trừ ctx) là các tham số IN theo thứ tự,
+// các tham số OUT lấy từ tag out. (ctx, ...) (result, error) ghi kết quả vào result, (ctx, ...) error chỉ với @Procedure
+func (r *Repository[T, ID]) makeRoutine(rt *routine, funcType reflect.Type, tag reflect.StructTag) (reflect.Value, error) {
+	calls, err := parseCallTags(tag)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	errType := reflect.TypeOf((*error)(nil)).Elem()
+	exec := funcType.NumOut() == 1 && funcType.Out(0) == errType
+	switch {
+	case exec && rt.kind == routineFunction:
+		return reflect.Value{}, fmt.Errorf("@Function phải trả về (result, error)")
+	case exec && len(rt.out) > 0:
+		return reflect.Value{}, fmt.Errorf("thủ tục có tham số OUT phải trả về (result, error)")
+	case !exec && (funcType.NumOut() != 2 || funcType.Out(1) != errType):
+		return reflect.Value{}, fmt.Errorf("%s phải trả về (result, error) hoặc error", rt.kind)
+	}
+	if r.DataSource != nil && r.DB != nil {
+		rows := !exec && !isScalarResult(funcType.Out(0))
+		if err := checkRoutineDialect(rt.kind, r.Dialector.Name(), rows, len(rt.out)); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+
+	return reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
+		ctx := args[0].Interface().(context.Context)
+		params := make([]any, 0, len(args)-1+len(rt.out))
+		for _, arg := range args[1:] {
+			params = append(params, arg.Interface())
+		}
+		for _, out := range rt.out {
+			params = append(params, Out(out))
+		}
+		if exec {
+			err := r.call(ctx, calls, func(ctx context.Context) error {
+				return r.CallProcedure(ctx, rt.name, nil, params...)
+			})
+			if err != nil {
+				return []reflect.Value{reflect.ValueOf(err)}
+			}
+			return []reflect.Value{reflect.Zero(errType)}
+		}
+		res := reflect.New(funcType.Out(0))
+		call := r.CallProcedure
+		if rt.kind == routineFunction {
+			call = r.CallFunction
+		}
+		err := r.call(ctx, calls, func(ctx context.Context) error {
+			return call(ctx, rt.name, res.Interface(), params...)
+		})
+		if err != nil {
+			return []reflect.Value{reflect.Zero(funcType.Out(0)), reflect.ValueOf(err)}
+		}
+		return []reflect.Value{res.Elem(), reflect.Zero(errType)}
+	}), nil
+}
@@ -3,10 +3,13 @@ package repo
 import (
 	"context"
 	"errors"
+	"maps"
 	"reflect"
+	"sync"
 
 	"github.com/xhkzeroone/go-database/db"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
 )
 
@@ -19,19 +22,29 @@ type Page[T any] struct {
 
 // IRepository định nghĩa interface cho repository generic
 // Giúp dễ mock/test trong unit test
-// Có thể mở rộng thêm các hàm khác nếu cần
+// Có thể mở rộng thêm các hàm khác nếu cần; các nhóm hàm tùy chọn (xóa mềm, ...) nằm ở interface riêng
+// như SoftDeleteRepository để không bắt mọi implementation/mock phải cài đặt
 type IRepository[T any, ID comparable] interface {
 	Insert(ctx context.Context, entity *T) error
+	InsertAll(ctx context.Context, entities []T, batchSize int) error
+	Upsert(ctx context.Context, entity *T, opts ...UpsertOption) error
+	UpsertAll(ctx context.Context, entities []T, batchSize int, opts ...UpsertOption) error
 	FindByID(ctx context.Context, id ID) (*T, error)
+	FindAllByIDs(ctx context.Context, ids []ID) ([]T, error)
 	FindWhere(ctx context.Context, query any, args ...any) ([]T, error)
 	FindOneWhere(ctx context.Context, query any, args ...any) (*T, error)
 	Update(ctx context.Context, entity *T) error
+	Patch(ctx context.Context, entity *T, fields ...string) (int64, error)
+	UpdateFields(ctx context.Context, id ID, values any, fields ...string) (int64, error)
+	Delete(ctx context.Context, entity *T) error
 	DeleteByID(ctx context.Context, id ID) error
+	DeleteAllByIDs(ctx context.Context, ids []ID) (int64, error)
 	ListAll(ctx context.Context) ([]T, error)
 	Count(ctx context.Context) (int64, error)
 	CountBy(ctx context.Context, query any, args ...any) (int64, error)
 	RawQuery(ctx context.Context, query string, args ...any) ([]T, error)
 	Exists(ctx context.Context, query any, args ...any) (bool, error)
+	ExistsByID(ctx context.Context, id ID) (bool, error)
 	Pageable(ctx context.Context, page int, pageSize int, query any, args ...any) (*Page[T], error)
 }
 
@@ -39,113 +52,422 @@ type IRepository[T any, ID comparable] interface {
 // T là kiểu entity, ID là kiểu khóa chính
 type Repository[T any, ID comparable] struct {
 	*db.DataSource
+	opts    options
+	meta    func() (*entityMeta, error)
+	finders *finderSet[T, ID] // các hàm dynamic đã wiring, xem Plans
 }
 
 // NewRepository khởi tạo repository mới
-func NewRepository[T any, ID comparable](db *db.DataSource) *Repository[T, ID] {
-	return &Repository[T, ID]{
-		DataSource: db,
+func NewRepository[T any, ID comparable](ds *db.DataSource, opts ...Option) *Repository[T, ID] {
+	r := &Repository[T, ID]{
+		DataSource: ds,
+		finders:    &finderSet[T, ID]{},
+		meta: sync.OnceValues(func() (*entityMeta, error) {
+			if ds == nil {
+				return parseEntityMeta(nil, new(T))
+			}
+			return parseEntityMeta(ds.DB, new(T))
+		}),
 	}
+	for _, o := range opts {
+		o(&r.opts)
+	}
+	return r
+}
+
+// With trả về bản sao repository có thêm option, ví dụ: r.With(repo.Preload("Orders")).FindByID(ctx, id)
+func (r *Repository[T, ID]) With(opts ...Option) *Repository[T, ID] {
+	clone := &Repository[T, ID]{
+		DataSource: r.DataSource,
+		opts:       r.opts.clone(),
+		meta:       r.meta,
+		finders:    r.finders,
+	}
+	for _, o := range opts {
+		o(&clone.opts)
+	}
+	return clone
+}
+
+// entity trả về metadata của T (schema, các field đặc biệt như @Version)
+func (r *Repository[T, ID]) entity() (*entityMeta, error) {
+	if r.meta != nil {
+		return r.meta()
+	}
+	if r.DataSource == nil {
+		return parseEntityMeta(nil, new(T))
+	}
+	return parseEntityMeta(r.DB, new(T))
+}
+
+// session truy vấn gốc gắn context và model, dùng transaction trong ctx nếu có, bỏ qua bản ghi đã xóa mềm
+func (r *Repository[T, ID]) session(ctx context.Context) *gorm.DB {
+	return r.scoped(ctx, new(T), excludeDeleted)
+}
+
+// reader truy vấn đọc gốc, lọc bản ghi đã xóa mềm theo WithDeleted/OnlyDeleted
+func (r *Repository[T, ID]) reader(ctx context.Context) *gorm.DB {
+	return r.scoped(ctx, new(T), r.opts.deleted)
+}
+
+// query truy vấn đọc entity, áp dụng các preload và lock đã cấu hình
+func (r *Repository[T, ID]) query(ctx context.Context) *gorm.DB {
+	return r.configured(r.reader(ctx))
+}
+
+// configured gắn các preload và lock đã cấu hình vào q
+func (r *Repository[T, ID]) configured(q *gorm.DB) *gorm.DB {
+	return applyLock(applyPreloads(q, r.opts.preloads), r.opts.lock)
 }
 
 // Insert thêm entity vào DB
 func (r *Repository[T, ID]) Insert(ctx context.Context, entity *T) error {
-	return r.WithContext(ctx).Model(new(T)).Create(entity).Error
+	return r.withGeneratedIDs(ctx, entity, func() error {
+		if r.opts.history {
+			return r.recordHistory(ctx, r.entityIDs(ctx, entity), func(ctx context.Context, r *Repository[T, ID]) error {
+				return r.Insert(ctx, entity)
+			})
+		}
+		meta, err := r.entity()
+		if err != nil {
+			return err
+		}
+		if err := r.prepareCreate(ctx, meta, entity); err != nil {
+			return err
+		}
+		if err := r.session(ctx).Create(entity).Error; err != nil {
+			return err
+		}
+		r.track(ctx, entity)
+		return nil
+	})
 }
 
 // FindByID tìm entity theo ID, trả về nil nếu không tìm thấy
 func (r *Repository[T, ID]) FindByID(ctx context.Context, id ID) (*T, error) {
+	meta, err := r.entity()
+	if err != nil {
+		return nil, err
+	}
+	cond, err := meta.idEq(id)
+	if err != nil {
+		return nil, err
+	}
 	entity := new(T)
-	err := r.WithContext(ctx).Model(new(T)).First(entity, id).Error
+	err = r.query(ctx).Where(cond).First(entity).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
 		}
 		return nil, err
 	}
+	r.track(ctx, entity)
 	return entity, nil
 }
 
 // FindWhere tìm danh sách entity theo điều kiện
 func (r *Repository[T, ID]) FindWhere(ctx context.Context, query any, args ...any) ([]T, error) {
 	var list []T
-	err := r.WithContext(ctx).Model(new(T)).Where(query, args...).Find(&list).Error
+	err := r.query(ctx).Where(query, args...).Find(&list).Error
+	if err == nil {
+		r.track(ctx, list)
+	}
 	return list, err
 }
 
 // FindOneWhere tìm một entity theo điều kiện
 func (r *Repository[T, ID]) FindOneWhere(ctx context.Context, query any, args ...any) (*T, error) {
 	var item T
-	err := r.WithContext(ctx).Model(new(T)).Where(query, args...).First(&item).Error
+	err := r.query(ctx).Where(query, args...).First(&item).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
 		}
 		return nil, err
 	}
+	r.track(ctx, &item)
 	return &item, nil
 }
 
-// Update cập nhật entity
+// Update cập nhật toàn bộ cột của entity theo khóa chính (kể cả giá trị zero), không insert khi chưa tồn tại.
+// Trả về ErrNotFound nếu không có dòng nào khớp khóa chính.
+// Nếu entity có field tag repo:"@Version", câu UPDATE kèm điều kiện version và tăng version lên 1;
+// không có dòng nào được cập nhật thì trả về *OptimisticLockError (errors.Is(err, ErrOptimisticLock)).
+// Trong unit of work (WithUnitOfWork) entity đã đọc trước đó chỉ ghi các cột thay đổi, không đổi gì thì không gửi UPDATE.
 func (r *Repository[T, ID]) Update(ctx context.Context, entity *T) error {
-	return r.WithContext(ctx).Model(new(T)).Save(entity).Error
+	if r.opts.history {
+		return r.recordHistory(ctx, r.entityIDs(ctx, entity), func(ctx context.Context, r *Repository[T, ID]) error {
+			return r.Update(ctx, entity)
+		})
+	}
+	meta, err := r.entity()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(entity).Elem()
+
+	var set map[string]any // nil: ghi toàn bộ cột
+	uow := unitOfWorkFrom(ctx)
+	if uow != nil {
+		if snap, ok := uow.get(meta.snapshotKey(ctx, rv)); ok {
+			if set = meta.changedColumns(ctx, rv, snap); len(set) == 0 {
+				return nil
+			}
+		}
+	}
+
+	audited := r.auditUpdate(ctx, meta, rv)
+	if set != nil {
+		maps.Copy(set, audited)
+	}
+
+	q := r.scoped(ctx, entity, excludeDeleted)
+	var old any
+	if meta.version != nil {
+		old = meta.bumpVersion(ctx, rv)
+		q = q.Where(versionEq(meta, old))
+		if set != nil {
+			set[meta.version.DBName], _ = meta.version.ValueOf(ctx, rv)
+		}
+	}
+	var res *gorm.DB
+	if set != nil {
+		res = q.Updates(set)
+	} else {
+		res = q.Select("*").Omit(meta.audit.createdColumns()...).Updates(entity)
+	}
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = meta.noRowsError(ctx, rv, old)
+	}
+	if res.Error != nil {
+		if meta.version != nil {
+			meta.restoreVersion(ctx, rv, old)
+		}
+		return res.Error
+	}
+	if uow != nil {
+		uow.put(meta.snapshotKey(ctx, rv), meta.snapshot(ctx, rv))
+	}
+	return nil
+}
+
+// Patch chỉ cập nhật các field khác zero của entity, hoặc đúng các field được chỉ định (kể cả zero).
+// Trả về số dòng bị ảnh hưởng, ErrNotFound (hoặc *OptimisticLockError nếu có @Version) khi không có dòng nào khớp.
+func (r *Repository[T, ID]) Patch(ctx context.Context, entity *T, fields ...string) (int64, error) {
+	if r.opts.history {
+		var n int64
+		err := r.recordHistory(ctx, r.entityIDs(ctx, entity), func(ctx context.Context, r *Repository[T, ID]) (err error) {
+			n, err = r.Patch(ctx, entity, fields...)
+			return err
+		})
+		return n, err
+	}
+	meta, err := r.entity()
+	if err != nil {
+		return 0, err
+	}
+	set, err := meta.assignments(ctx, entity, fields)
+	if err != nil {
+		return 0, err
+	}
+	if len(set) == 0 {
+		return 0, errNoFieldsToUpdate
+	}
+	rv := reflect.ValueOf(entity).Elem()
+	maps.Copy(set, r.auditUpdate(ctx, meta, rv))
+	q := r.scoped(ctx, entity, excludeDeleted)
+
+	var old any
+	if meta.version != nil {
+		old = meta.bumpVersion(ctx, rv)
+		set[meta.version.DBName], _ = meta.version.ValueOf(ctx, rv)
+		q = q.Where(versionEq(meta, old))
+	}
+	res := q.Updates(set)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = meta.noRowsError(ctx, rv, old)
+	}
+	if res.Error != nil && meta.version != nil {
+		meta.restoreVersion(ctx, rv, old)
+	}
+	r.untrack(ctx, meta, rv)
+	return res.RowsAffected, res.Error
+}
+
+// UpdateFields cập nhật một phần theo ID. values là map[string]any (key là tên field hoặc cột) hoặc *T/T
+// (chỉ ghi field khác zero); fields giới hạn các field được ghi.
+// Có @Version: nếu values chứa version thì đó là version mong đợi, câu UPDATE kèm điều kiện version và
+// trả về *OptimisticLockError khi không khớp; values không chứa version thì version chỉ được tăng lên 1,
+// không kiểm tra optimistic lock (ghi đè thay đổi đồng thời của Update/Patch).
+// Trả về số dòng bị ảnh hưởng, ErrNotFound khi không có dòng nào khớp.
+func (r *Repository[T, ID]) UpdateFields(ctx context.Context, id ID, values any, fields ...string) (int64, error) {
+	if r.opts.history {
+		var n int64
+		err := r.recordHistory(ctx, idList(id), func(ctx context.Context, r *Repository[T, ID]) (err error) {
+			n, err = r.UpdateFields(ctx, id, values, fields...)
+			return err
+		})
+		return n, err
+	}
+	meta, err := r.entity()
+	if err != nil {
+		return 0, err
+	}
+	set, err := meta.assignments(ctx, values, fields)
+	if err != nil {
+		return 0, err
+	}
+	if len(set) == 0 {
+		return 0, errNoFieldsToUpdate
+	}
+	maps.Copy(set, r.auditColumns(ctx, meta))
+	cond, err := meta.idEq(id)
+	if err != nil {
+		return 0, err
+	}
+	q := r.session(ctx).Where(cond)
+	var expected any
+	if meta.version != nil {
+		column := clause.Column{Table: clause.CurrentTable, Name: meta.version.DBName}
+		if v, ok := set[meta.version.DBName]; ok {
+			expected = v
+			q = q.Where(versionEq(meta, expected))
+		}
+		set[meta.version.DBName] = gorm.Expr("? + 1", column)
+	}
+	res := q.Updates(set)
+	r.untrackIDs(ctx, meta, id)
+	if res.Error == nil && res.RowsAffected == 0 {
+		if expected != nil {
+			return 0, &OptimisticLockError{Table: meta.table, ID: id, Version: expected}
+		}
+		return 0, ErrNotFound
+	}
+	return res.RowsAffected, res.Error
 }
 
-// DeleteByID xóa entity theo ID
+// Delete xóa entity, kiểm tra version nếu entity có field tag repo:"@Version"
+func (r *Repository[T, ID]) Delete(ctx context.Context, entity *T) error {
+	if r.opts.history {
+		return r.recordHistory(ctx, r.entityIDs(ctx, entity), func(ctx context.Context, r *Repository[T, ID]) error {
+			return r.Delete(ctx, entity)
+		})
+	}
+	meta, err := r.entity()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(entity).Elem()
+	r.untrack(ctx, meta, rv)
+	q := r.scoped(ctx, entity, excludeDeleted)
+	if meta.version == nil {
+		return r.remove(q, meta, entity).Error
+	}
+
+	old, _ := meta.version.ValueOf(ctx, rv)
+	res := r.remove(q.Where(versionEq(meta, old)), meta, entity)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return meta.noRowsError(ctx, rv, old)
+	}
+	return res.Error
+}
+
+// DeleteByID xóa entity theo ID (không kiểm tra version, dùng Delete để có optimistic lock)
 func (r *Repository[T, ID]) DeleteByID(ctx context.Context, id ID) error {
-	return r.WithContext(ctx).Model(new(T)).Delete(new(T), id).Error
+	if r.opts.history {
+		return r.recordHistory(ctx, idList(id), func(ctx context.Context, r *Repository[T, ID]) error {
+			return r.DeleteByID(ctx, id)
+		})
+	}
+	meta, err := r.entity()
+	if err != nil {
+		return err
+	}
+	cond, err := meta.idEq(id)
+	if err != nil {
+		return err
+	}
+	err = r.remove(r.session(ctx).Where(cond), meta, new(T)).Error
+	r.untrackIDs(ctx, meta, id)
+	return err
 }
 
 // ListAll lấy tất cả entity
 func (r *Repository[T, ID]) ListAll(ctx context.Context) ([]T, error) {
 	var list []T
-	err := r.WithContext(ctx).Model(new(T)).Find(&list).Error
+	err := r.query(ctx).Find(&list).Error
+	if err == nil {
+		r.track(ctx, list)
+	}
 	return list, err
 }
 
 // Count đếm tổng số entity
 func (r *Repository[T, ID]) Count(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.WithContext(ctx).Model(new(T)).Count(&count).Error
+	err := r.reader(ctx).Count(&count).Error
 	return count, err
 }
 
 // CountBy đếm entity theo điều kiện
 func (r *Repository[T, ID]) CountBy(ctx context.Context, query any, args ...any) (int64, error) {
 	var count int64
-	err := r.WithContext(ctx).Model(new(T)).Where(query, args...).Count(&count).Error
+	err := r.reader(ctx).Where(query, args...).Count(&count).Error
 	return count, err
 }
 
 // RawQuery thực thi truy vấn SQL thô
 func (r *Repository[T, ID]) RawQuery(ctx context.Context, query string, args ...any) ([]T, error) {
 	var results []T
-	err := r.WithContext(ctx).Raw(query, args...).Scan(&results).Error
+	err := r.Conn(ctx).Raw(query, args...).Scan(&results).Error
 	return results, err
 }
 
+// ExistsByID kiểm tra entity với ID (kể cả khóa nhiều cột) có tồn tại không
+func (r *Repository[T, ID]) ExistsByID(ctx context.Context, id ID) (bool, error) {
+	meta, err := r.entity()
+	if err != nil {
+		return false, err
+	}
+	cond, err := meta.idEq(id)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	err = r.reader(ctx).Where(cond).Count(&count).Error
+	return count > 0, err
+}
+
 // Exists kiểm tra có entity nào thỏa điều kiện không (an toàn, không dùng raw SQL)
 func (r *Repository[T, ID]) Exists(ctx context.Context, query any, args ...any) (bool, error) {
 	var count int64
-	err := r.WithContext(ctx).Model(new(T)).Where(query, args...).Count(&count).Error
+	err := r.reader(ctx).Where(query, args...).Count(&count).Error
 	return count > 0, err
 }
 
 // Pageable phân trang kết quả truy vấn
 func (r *Repository[T, ID]) Pageable(ctx context.Context, page int, pageSize int, query any, args ...any) (*Page[T], error) {
+	return r.page(ctx, page, pageSize, func(q *gorm.DB) *gorm.DB {
+		return q.Where(query, args...)
+	})
+}
+
+// page phân trang các entity thỏa điều kiện do scope gắn vào truy vấn
+func (r *Repository[T, ID]) page(ctx context.Context, page int, pageSize int, scope func(*gorm.DB) *gorm.DB) (*Page[T], error) {
 	var items []T
 	var total int64
 
 	// Đếm tổng số bản ghi
-	if err := r.WithContext(ctx).Model(new(T)).Where(query, args...).Count(&total).Error; err != nil {
+	if err := scope(r.reader(ctx)).Count(&total).Error; err != nil {
 		return nil, err
 	}
 
 	// Lấy dữ liệu theo trang
 	offset := (page - 1) * pageSize
-	if err := r.WithContext(ctx).Where(query, args...).Limit(pageSize).Offset(offset).Find(&items).Error; err != nil {
+	if err := scope(r.query(ctx)).Limit(pageSize).Offset(offset).Find(&items).Error; err != nil {
 		return nil, err
 	}
+	r.track(ctx, items)
 
 	return &Page[T]{
 		Items:      items,
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/xhkzeroone/go-database/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDataSource mở một sqlite trong bộ nhớ riêng cho test và tạo bảng cho các model
+func newTestDataSource(t testing.TB, models ...any) *db.DataSource {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mỗi connection của ":memory:" là một DB khác nhau
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := gdb.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return &db.DataSource{DB: gdb}
+}
+
+type versionedItem struct {
+	ID      uint
+	Name    string
+	Total   int
+	Version int64 `repo:"@Version"`
+}
+
+type plainItem struct {
+	ID   uint
+	Name string
+}
+
+func TestPatchNoFields(t *testing.T) {
+	ds := newTestDataSource(t, &plainItem{})
+	r := NewRepository[plainItem, uint](ds)
+	ctx := context.Background()
+	item := &plainItem{Name: "a"}
+	if err := r.Insert(ctx, item); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Patch(ctx, &plainItem{ID: item.ID}); !errors.Is(err, errNoFieldsToUpdate) {
+		t.Errorf("Patch không có field khác zero: lỗi = %v", err)
+	}
+	if _, err := r.UpdateFields(ctx, item.ID, map[string]any{}); !errors.Is(err, errNoFieldsToUpdate) {
+		t.Errorf("UpdateFields không có field: lỗi = %v", err)
+	}
+}
+
+func TestUpdateFieldsVersion(t *testing.T) {
+	ds := newTestDataSource(t, &versionedItem{})
+	r := NewRepository[versionedItem, uint](ds)
+	ctx := context.Background()
+	item := &versionedItem{Name: "a"}
+	if err := r.Insert(ctx, item); err != nil {
+		t.Fatal(err)
+	}
+
+	// Không có version mong đợi: chỉ tăng version
+	if _, err := r.UpdateFields(ctx, item.ID, map[string]any{"Name": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	// Version mong đợi đã cũ
+	_, err := r.UpdateFields(ctx, item.ID, map[string]any{"Name": "c", "Version": item.Version})
+	var lockErr *OptimisticLockError
+	if !errors.As(err, &lockErr) || !errors.Is(err, ErrOptimisticLock) || lockErr.Version != item.Version {
+		t.Fatalf("version cũ: lỗi = %v", err)
+	}
+	if n, err := r.UpdateFields(ctx, item.ID, map[string]any{"Name": "c", "Version": item.Version + 1}); err != nil || n != 1 {
+		t.Fatalf("version mới: n = %d, lỗi = %v", n, err)
+	}
+	got, err := r.FindByID(ctx, item.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "c" || got.Version != item.Version+2 {
+		t.Errorf("sau cập nhật: %+v", got)
+	}
+	if _, err := r.UpdateFields(ctx, item.ID+1, map[string]any{"Name": "d"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("không tồn tại: lỗi = %v", err)
+	}
+}
+
+func TestUntrackAfterWriteByID(t *testing.T) {
+	ds := newTestDataSource(t, &plainItem{})
+	r := NewRepository[plainItem, uint](ds)
+	ctx := WithUnitOfWork(context.Background())
+	items := []plainItem{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	if err := r.InsertAll(ctx, items, 10); err != nil {
+		t.Fatal(err)
+	}
+	tracked, err := r.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// UpdateFields bỏ snapshot: Update sau đó ghi lại toàn bộ cột thay vì coi entity là không đổi
+	if _, err := r.UpdateFields(ctx, tracked[0].ID, map[string]any{"Name": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(ctx, &tracked[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.FindByID(ctx, tracked[0].ID); err != nil || got.Name != "a" {
+		t.Errorf("Update sau UpdateFields: %+v, %v", got, err)
+	}
+
+	// Xóa theo ID bỏ snapshot: Update entity đã xóa báo ErrNotFound thay vì bỏ qua vì không có thay đổi
+	if err := r.DeleteByID(ctx, tracked[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(ctx, &tracked[1]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update sau DeleteByID: lỗi = %v", err)
+	}
+	if _, err := r.DeleteAllByIDs(ctx, []uint{tracked[2].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(ctx, &tracked[2]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update sau DeleteAllByIDs: lỗi = %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SoftDeleteRepository các thao tác với bản ghi đã xóa mềm, *Repository[T, ID] cài đặt sẵn.
+// Nhúng cùng IRepository khi cần, ví dụ trong interface khai báo cho repogen.
+type SoftDeleteRepository[T any, ID comparable] interface {
+	Restore(ctx context.Context, id ID) error
+	FindWithDeleted(ctx context.Context, query any, args ...any) ([]T, error)
+	FindOnlyDeleted(ctx context.Context, query any, args ...any) ([]T, error)
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+var _ SoftDeleteRepository[struct{}, int] = (*Repository[struct{}, int])(nil)
+
+// deletedScope phạm vi bản ghi đã xóa mềm khi đọc
+type deletedScope int
+
+const (
+	excludeDeleted deletedScope = iota // mặc định: bỏ qua bản ghi đã xóa mềm
+	includeDeleted                     // đọc cả bản ghi đã xóa mềm
+	onlyDeleted                        // chỉ đọc bản ghi đã xóa mềm
+)
+
+// WithDeleted đọc cả các bản ghi đã xóa mềm
+func WithDeleted() Option {
+	return func(o *options) {
+		o.deleted = includeDeleted
+	}
+}
+
+// OnlyDeleted chỉ đọc các bản ghi đã xóa mềm
+func OnlyDeleted() Option {
+	return func(o *options) {
+		o.deleted = onlyDeleted
+	}
+}
+
+// softDelete field đánh dấu xóa mềm: kiểu gorm.DeletedAt (GORM tự xử lý)
+// hoặc *time.Time/sql.NullTime có tag repo:"@SoftDelete" (repository tự thêm điều kiện)
+type softDelete struct {
+	field  *schema.Field
+	native bool // kiểu gorm.DeletedAt
+}
+
+var (
+	gormDeletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	nullTimeType      = reflect.TypeOf(sql.NullTime{})
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// parseSoftDelete kiểm tra kiểu của field xóa mềm, tagged cho biết field có tag repo:"@SoftDelete"
+func parseSoftDelete(s *schema.Schema, field *schema.Field, tagged bool) (*softDelete, error) {
+	switch {
+	case field.FieldType == gormDeletedAtType:
+		return &softDelete{field: field, native: true}, nil
+	case !tagged:
+		return nil, nil
+	case field.FieldType.Kind() == reflect.Ptr && field.IndirectFieldType == timeType,
+		field.FieldType == nullTimeType:
+		return &softDelete{field: field}, nil
+	}
+	return nil, fmt.Errorf("repo: field @SoftDelete %s.%s phải là gorm.DeletedAt, *time.Time hoặc sql.NullTime", s.Name, field.Name)
+}
+
+func (d *softDelete) column() clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: d.field.DBName}
+}
+
+// scope thêm điều kiện xóa mềm vào truy vấn theo phạm vi
+func (d *softDelete) scope(q *gorm.DB, scope deletedScope) *gorm.DB {
+	switch scope {
+	case includeDeleted:
+		if d.native {
+			q = q.Unscoped()
+		}
+	case onlyDeleted:
+		if d.native {
+			q = q.Unscoped()
+		}
+		q = q.Where(clause.Neq{Column: d.column(), Value: nil})
+	default:
+		if !d.native {
+			q = q.Where(clause.Eq{Column: d.column(), Value: nil})
+		}
+	}
+	return q
+}
+
+// scoped truy vấn gốc gắn context và model value, lọc bản ghi đã xóa mềm theo phạm vi
+func (r *Repository[T, ID]) scoped(ctx context.Context, value any, scope deletedScope) *gorm.DB {
+	q := r.Conn(ctx).Model(value)
+	if meta, err := r.entity(); err == nil && meta.softDelete != nil {
+		q = meta.softDelete.scope(q, scope)
+	}
+	return q
+}
+
+// remove xóa các dòng khớp q: entity có field xóa mềm thì chỉ gán thời điểm xóa, ngược lại xóa hẳn
+func (r *Repository[T, ID]) remove(q *gorm.DB, meta *entityMeta, value any) *gorm.DB {
+	if meta.softDelete == nil || meta.softDelete.native {
+		return q.Delete(value)
+	}
+	return q.UpdateColumn(meta.softDelete.field.DBName, q.NowFunc())
+}
+
+func (r *Repository[T, ID]) softDeleteField() (*entityMeta, error) {
+	meta, err := r.entity()
+	if err != nil {
+		return nil, err
+	}
+	if meta.softDelete == nil {
+		return nil, fmt.Errorf("repo: %s không có field xóa mềm (gorm.DeletedAt hoặc tag repo:\"@SoftDelete\")", meta.schema.Name)
+	}
+	return meta, nil
+}
+
+// Restore khôi phục entity đã xóa mềm, trả về ErrNotFound nếu không có bản ghi đã xóa với ID này
+func (r *Repository[T, ID]) Restore(ctx context.Context, id ID) error {
+	if r.opts.history {
+		return r.recordHistory(ctx, idList(id), func(ctx context.Context, r *Repository[T, ID]) error {
+			return r.Restore(ctx, id)
+		})
+	}
+	meta, err := r.softDeleteField()
+	if err != nil {
+		return err
+	}
+	cond, err := meta.idEq(id)
+	if err != nil {
+		return err
+	}
+	res := r.scoped(ctx, new(T), onlyDeleted).Where(cond).UpdateColumn(meta.softDelete.field.DBName, nil)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return res.Error
+}
+
+// FindWithDeleted tìm entity theo điều kiện, kể cả bản ghi đã xóa mềm
+func (r *Repository[T, ID]) FindWithDeleted(ctx context.Context, query any, args ...any) ([]T, error) {
+	return r.With(WithDeleted()).FindWhere(ctx, query, args...)
+}
+
+// FindOnlyDeleted tìm các bản ghi đã xóa mềm thỏa điều kiện
+func (r *Repository[T, ID]) FindOnlyDeleted(ctx context.Context, query any, args ...any) ([]T, error) {
+	return r.With(OnlyDeleted()).FindWhere(ctx, query, args...)
+}
+
+// PurgeDeleted xóa hẳn các bản ghi đã xóa mềm quá olderThan, trả về số dòng bị xóa.
+// Với WithHistory, khóa chính các bản ghi bị xóa được đọc trước rồi xóa theo khóa, ghi Revision trong cùng transaction.
+func (r *Repository[T, ID]) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	meta, err := r.softDeleteField()
+	if err != nil {
+		return 0, err
+	}
+	older := clause.Lt{Column: meta.softDelete.column(), Value: r.Conn(ctx).NowFunc().Add(-olderThan)}
+	if !r.opts.history {
+		res := r.Conn(ctx).Unscoped().Where(older).Delete(new(T))
+		return res.RowsAffected, res.Error
+	}
+
+	var n int64
+	err = r.Transactional(ctx, func(ctx context.Context) error {
+		var rows []T
+		if err := r.scoped(ctx, new(T), onlyDeleted).Where(older).Find(&rows).Error; err != nil {
+			return err
+		}
+		ids := make([]any, len(rows))
+		for i := range rows {
+			ids[i] = meta.primaryKey(ctx, reflect.ValueOf(&rows[i]).Elem())
+		}
+		return r.recordHistory(ctx, idList(ids...), func(ctx context.Context, r *Repository[T, ID]) error {
+			for _, chunk := range chunkIDs(ids, r.idChunkSize()) {
+				cond, err := idIn(meta, chunk)
+				if err != nil {
+					return err
+				}
+				res := r.Conn(ctx).Unscoped().Where(cond).Delete(new(T))
+				if res.Error != nil {
+					return res.Error
+				}
+				n += res.RowsAffected
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
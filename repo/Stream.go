@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"sync/atomic"
+
+	"github.com/xhkzeroone/go-database/db"
+	"gorm.io/gorm"
+)
+
+// StreamRepository đọc entity không nạp hết vào bộ nhớ, *Repository[T, ID] cài đặt sẵn
+type StreamRepository[T any, ID comparable] interface {
+	StreamAll(ctx context.Context) iter.Seq2[T, error]
+	StreamWhere(ctx context.Context, query any, args ...any) iter.Seq2[T, error]
+	FindInBatches(ctx context.Context, size int, fn func(batch []T) error) error
+}
+
+var _ StreamRepository[struct{}, int] = (*Repository[struct{}, int])(nil)
+
+// defaultFetchSize số dòng mỗi lần FETCH từ cursor phía server (postgres)
+const defaultFetchSize = 1000
+
+// FetchSize số dòng mỗi lần đọc từ cursor khi stream trên postgres
+func FetchSize(n int) Option {
+	return func(o *options) {
+		o.fetchSize = n
+	}
+}
+
+var cursorSeq atomic.Uint64
+
+// StreamAll duyệt toàn bộ entity mà không nạp hết vào bộ nhớ
+func (r *Repository[T, ID]) StreamAll(ctx context.Context) iter.Seq2[T, error] {
+	return r.stream(ctx, r.query)
+}
+
+// StreamWhere duyệt các entity thỏa điều kiện mà không nạp hết vào bộ nhớ
+func (r *Repository[T, ID]) StreamWhere(ctx context.Context, query any, args ...any) iter.Seq2[T, error] {
+	return r.stream(ctx, func(ctx context.Context) *gorm.DB {
+		return r.query(ctx).Where(query, args...)
+	})
+}
+
+// FindInBatches đọc entity theo từng lô size phần tử (phân trang theo khóa chính), gọi fn cho mỗi lô.
+// fn trả lỗi thì dừng và trả về lỗi đó.
+func (r *Repository[T, ID]) FindInBatches(ctx context.Context, size int, fn func(batch []T) error) error {
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	var batch []T
+	return r.query(ctx).FindInBatches(&batch, size, func(_ *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// stream tạo iterator trên kết quả của build. Postgres dùng cursor phía server (DECLARE/FETCH) trong transaction
+// chỉ đọc (hoặc transaction sẵn có trong ctx); các DB khác đọc tuần tự từ *sql.Rows, MySQL driver không đệm toàn bộ kết quả.
+// Preload không được áp dụng khi stream; entity không được theo dõi bởi unit of work.
+func (r *Repository[T, ID]) stream(ctx context.Context, build func(ctx context.Context) *gorm.DB) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var err error
+		if r.Dialector.Name() == "postgres" {
+			err = r.Transactional(ctx, func(ctx context.Context) error {
+				return r.streamCursor(ctx, build(ctx), yield)
+			}, &sql.TxOptions{ReadOnly: true})
+		} else {
+			err = r.streamRows(build(ctx), yield)
+		}
+		if err != nil && !errors.Is(err, errStopStream) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// errStopStream vòng lặp dừng sớm (yield trả false), không phải lỗi thật
+var errStopStream = errors.New("repo: stream stopped")
+
+func (r *Repository[T, ID]) streamRows(q *gorm.DB, yield func(T, error) bool) error {
+	rows, err := q.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item T
+		if err := q.ScanRows(rows, &item); err != nil {
+			return err
+		}
+		if !yield(item, nil) {
+			return errStopStream
+		}
+	}
+	return rows.Err()
+}
+
+func (r *Repository[T, ID]) streamCursor(ctx context.Context, q *gorm.DB, yield func(T, error) bool) error {
+	stmt := q.Session(&gorm.Session{DryRun: true}).Find(new([]T)).Statement
+	if stmt.Error != nil {
+		return stmt.Error
+	}
+	tx, _ := db.TxFromContext(ctx)
+	tx = tx.WithContext(ctx)
+	name := fmt.Sprintf("repo_cursor_%d", cursorSeq.Add(1))
+	if _, err := tx.Statement.ConnPool.ExecContext(ctx, "DECLARE "+name+" NO SCROLL CURSOR FOR "+stmt.SQL.String(), stmt.Vars...); err != nil {
+		return err
+	}
+	defer tx.Exec("CLOSE " + name)
+
+	size := r.opts.fetchSize
+	if size <= 0 {
+		size = defaultFetchSize
+	}
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", size, name)
+	for {
+		var batch []T
+		if err := tx.Raw(fetch).Scan(&batch).Error; err != nil {
+			return err
+		}
+		for _, item := range batch {
+			if !yield(item, nil) {
+				return errStopStream
+			}
+		}
+		if len(batch) < size {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type unitOfWorkKey struct{}
+
+// unitOfWork lưu snapshot các entity đã đọc trong phạm vi một context để Update chỉ ghi cột thay đổi.
+// Snapshot sống cùng context, hết context là được giải phóng.
+type unitOfWork struct {
+	mu        sync.Mutex
+	snapshots map[string]map[string]any // "<bảng>#<khóa chính>" -> cột -> giá trị
+}
+
+// WithUnitOfWork bật dirty checking cho các repository gọi với ctx trả về:
+// entity đọc qua FindByID, FindWhere, hàm dynamic, ... được chụp snapshot,
+// Update chỉ ghi các cột khác snapshot và bỏ qua hẳn câu UPDATE khi không có gì thay đổi.
+func WithUnitOfWork(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, unitOfWorkKey{}, &unitOfWork{snapshots: map[string]map[string]any{}})
+}
+
+func unitOfWorkFrom(ctx context.Context) *unitOfWork {
+	uow, _ := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+	return uow
+}
+
+func (u *unitOfWork) get(key string) (map[string]any, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	snap, ok := u.snapshots[key]
+	return snap, ok
+}
+
+func (u *unitOfWork) put(key string, snap map[string]any) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.snapshots[key] = snap
+}
+
+func (u *unitOfWork) remove(key string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.snapshots, key)
+}
+
+// snapshotKey khóa của entity trong unit of work: bảng + giá trị các cột khóa chính
+func (m *entityMeta) snapshotKey(ctx context.Context, rv reflect.Value) string {
+	values := make([]any, len(m.schema.PrimaryFields))
+	for i, field := range m.schema.PrimaryFields {
+		values[i], _ = field.ValueOf(ctx, rv)
+	}
+	return m.keyOf(values)
+}
+
+// idSnapshotKey khóa trong unit of work của entity có khóa chính id, cùng dạng snapshotKey
+func (m *entityMeta) idSnapshotKey(id any) (string, error) {
+	values, err := m.idValues(id)
+	if err != nil {
+		return "", err
+	}
+	return m.keyOf(values), nil
+}
+
+func (m *entityMeta) keyOf(values []any) string {
+	parts := make([]string, 0, len(values)+1)
+	parts = append(parts, m.table)
+	for _, v := range values {
+		parts = append(parts, fmt.Sprint(v))
+	}
+	return strings.Join(parts, "#")
+}
+
+// snapshot chụp giá trị các cột của entity (sao chép pointer/slice/map để thay đổi sau đó không ảnh hưởng snapshot)
+func (m *entityMeta) snapshot(ctx context.Context, rv reflect.Value) map[string]any {
+	snap := make(map[string]any, len(m.schema.DBNames))
+	for _, field := range m.schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		v, _ := field.ValueOf(ctx, rv)
+		snap[field.DBName] = copyValue(v)
+	}
+	return snap
+}
+
+// changedColumns so sánh entity với snapshot, trả về các cột đã thay đổi (không gồm khóa chính)
+func (m *entityMeta) changedColumns(ctx context.Context, rv reflect.Value, snap map[string]any) map[string]any {
+	changed := map[string]any{}
+	for _, field := range m.schema.Fields {
+		if field.DBName == "" || field.PrimaryKey || !field.Updatable {
+			continue
+		}
+		v, _ := field.ValueOf(ctx, rv)
+		if !reflect.DeepEqual(snap[field.DBName], copyValue(v)) {
+			changed[field.DBName] = v
+		}
+	}
+	return changed
+}
+
+func copyValue(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return v
+		}
+		cp := reflect.New(rv.Elem().Type())
+		cp.Elem().Set(rv.Elem())
+		return cp.Interface()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(cp, rv)
+		return cp.Interface()
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return cp.Interface()
+	}
+	return v
+}
+
+// track chụp snapshot cho entity/slice entity vừa đọc nếu ctx có unit of work
+func (r *Repository[T, ID]) track(ctx context.Context, value any) {
+	uow := unitOfWorkFrom(ctx)
+	if uow == nil {
+		return
+	}
+	meta, err := r.entity()
+	if err != nil {
+		return
+	}
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch {
+	case rv.Kind() == reflect.Slice:
+		for i := 0; i < rv.Len(); i++ {
+			if item := reflect.Indirect(rv.Index(i)); item.Type() == meta.schema.ModelType {
+				uow.put(meta.snapshotKey(ctx, item), meta.snapshot(ctx, item))
+			}
+		}
+	case rv.Type() == meta.schema.ModelType:
+		uow.put(meta.snapshotKey(ctx, rv), meta.snapshot(ctx, rv))
+	}
+}
+
+// untrack bỏ snapshot của entity (sau khi xóa hoặc cập nhật một phần)
+func (r *Repository[T, ID]) untrack(ctx context.Context, meta *entityMeta, rv reflect.Value) {
+	if uow := unitOfWorkFrom(ctx); uow != nil {
+		uow.remove(meta.snapshotKey(ctx, rv))
+	}
+}
+
+// untrackIDs bỏ snapshot của các entity theo ID sau khi ghi không qua entity (UpdateFields, DeleteByID, DeleteAllByIDs)
+func (r *Repository[T, ID]) untrackIDs(ctx context.Context, meta *entityMeta, ids ...ID) {
+	uow := unitOfWorkFrom(ctx)
+	if uow == nil {
+		return
+	}
+	for _, id := range ids {
+		if key, err := meta.idSnapshotKey(id); err == nil {
+			uow.remove(key)
+		}
+	}
+}